@@ -0,0 +1,134 @@
+// Package broker bridges evently to message brokers. Publisher and Subscriber abstract
+// a broker, the MemoryBroker implements both in memory, package natsjs on NATS JetStream.
+// Events are sent as CloudEvents in binary content mode (see Message).
+package broker
+
+import (
+	"context"
+	"time"
+
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/event"
+	"github.com/openyard/evently/query/consume"
+	"github.com/openyard/evently/query/subscription"
+)
+
+const (
+	defaultMaxDeliveries   = 5
+	defaultRedeliveryDelay = time.Second
+)
+
+// Publisher publishes events to a topic of a message broker
+type Publisher interface {
+	Publish(ctx context.Context, topic string, events ...*event.Event) error
+}
+
+// Subscriber delivers the events of a topic of a message broker to a consume.Consumer.
+// The consumer gets each event as es.Entry with the topic as stream and the sequence of
+// the message in the topic as global position. Events are delivered in order, a failed
+// event is redelivered before the next one.
+type Subscriber interface {
+	Subscribe(topic string, consumer consume.Consumer, opts ...DeliveryOption) (Subscription, error)
+}
+
+// Subscription of a topic
+type Subscription interface {
+	// Close stops the delivery of events
+	Close()
+}
+
+// DeliveryOption configures the Delivery of a subscription
+type DeliveryOption func(d *Delivery)
+
+// Delivery holds the configuration of a subscription. An event consumed successfully
+// is acknowledged, a failed one is redelivered after the redelivery delay. An event
+// failing on its last delivery is dropped.
+type Delivery struct {
+	// Group names a durable subscription. Subscriptions of the same group resume where
+	// the group stopped, without group a subscription starts at the first event of the topic.
+	Group string
+	// Ack is called with the entries consumed successfully
+	Ack subscription.AckFunc
+	// Nack is called with the entries whose consumer failed
+	Nack subscription.NackFunc
+	// MaxDeliveries is the number of deliveries before an event is dropped, 0 is unlimited
+	MaxDeliveries int
+	// RedeliveryDelay is the time to wait before a failed event is delivered again
+	RedeliveryDelay time.Duration
+}
+
+// NewDelivery returns a Delivery configured by the given options. By default, an event
+// is delivered up to 5 times with 1s between the deliveries.
+func NewDelivery(opts ...DeliveryOption) *Delivery {
+	d := &Delivery{
+		Ack:             func(_ ...*es.Entry) {},
+		Nack:            func(_ ...*es.Entry) {},
+		MaxDeliveries:   defaultMaxDeliveries,
+		RedeliveryDelay: defaultRedeliveryDelay,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// WithGroup subscribes durably as member of the given group
+func WithGroup(group string) DeliveryOption {
+	return func(d *Delivery) {
+		d.Group = group
+	}
+}
+
+// WithAckFunc calls the given ackFunc with the entries consumed successfully
+func WithAckFunc(ackFunc subscription.AckFunc) DeliveryOption {
+	return func(d *Delivery) {
+		d.Ack = ackFunc
+	}
+}
+
+// WithNackFunc calls the given nackFunc with the entries whose consumer failed
+func WithNackFunc(nackFunc subscription.NackFunc) DeliveryOption {
+	return func(d *Delivery) {
+		d.Nack = nackFunc
+	}
+}
+
+// WithMaxDeliveries sets the number of deliveries before an event is dropped, 0 is unlimited
+func WithMaxDeliveries(n int) DeliveryOption {
+	return func(d *Delivery) {
+		d.MaxDeliveries = n
+	}
+}
+
+// WithRedeliveryDelay sets the time to wait before a failed event is delivered again
+func WithRedeliveryDelay(delay time.Duration) DeliveryOption {
+	return func(d *Delivery) {
+		d.RedeliveryDelay = delay
+	}
+}
+
+// Outcome of a delivery
+type Outcome int
+
+const (
+	// Acked events were consumed successfully
+	Acked Outcome = iota
+	// Redeliver events failed and must be delivered again
+	Redeliver
+	// Dropped events failed on their last delivery
+	Dropped
+)
+
+// Consume passes the entry to the consumer, calls Ack or Nack and returns the outcome
+// of the given delivery, counting from 1
+func (d *Delivery) Consume(ctx context.Context, consumer consume.Consumer, entry *es.Entry, delivery int) Outcome {
+	if err := consumer.Handle(&consume.Context{Context: ctx}, entry); err != nil {
+		d.Nack(entry)
+		if d.MaxDeliveries > 0 && delivery >= d.MaxDeliveries {
+			return Dropped
+		}
+		return Redeliver
+	}
+	d.Ack(entry)
+	return Acked
+}
@@ -0,0 +1,135 @@
+package broker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openyard/evently/broker"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/event"
+	"github.com/openyard/evently/query/consume"
+)
+
+func TestMessage(t *testing.T) {
+	e := event.NewIntegrationEvent("customer-created", "1",
+		event.WithPayload([]byte(`{"name":"Jane"}`)),
+		event.WithMetadata(map[string]string{"correlation-id": "c&1"})).Record(time.Now())
+	msg := broker.NewMessage("customers", "/customers", e)
+	for header, expected := range map[string]string{
+		broker.HeaderSpecVersion: "1.0",
+		broker.HeaderID:          e.ID(),
+		broker.HeaderSource:      "/customers",
+		broker.HeaderType:        "customer-created",
+		broker.HeaderSubject:     "1",
+		broker.HeaderContentType: "application/json",
+	} {
+		if msg.Headers[header] != expected {
+			t.Errorf("expected header %s=%q, got %q", header, expected, msg.Headers[header])
+		}
+	}
+
+	decoded, err := msg.Event()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ID() != e.ID() || decoded.Kind() != event.IntegrationEvent || decoded.AggregateID() != "1" ||
+		!decoded.OccurredAt().Equal(e.OccurredAt()) || !decoded.RecordedAt().Equal(e.RecordedAt()) ||
+		string(decoded.Payload()) != `{"name":"Jane"}` || decoded.Metadata()["correlation-id"] != "c&1" {
+		t.Errorf("unexpected decoded event %+v", decoded)
+	}
+
+	msg.Headers[broker.HeaderSpecVersion] = "0.3"
+	if _, err := msg.Event(); err == nil {
+		t.Error("expected unsupported specversion to fail")
+	}
+}
+
+func TestMemoryBroker(t *testing.T) {
+	b := broker.NewMemoryBroker("/test")
+	_ = b.Publish(context.Background(), "customers", event.NewIntegrationEvent("created", "1"), event.NewIntegrationEvent("created", "2"))
+
+	rec := &recorder{failures: map[string]int{"2": 1, "3": 10}}
+	var nacked []string
+	s, err := b.Subscribe("customers", rec,
+		broker.WithMaxDeliveries(3),
+		broker.WithRedeliveryDelay(time.Millisecond),
+		broker.WithNackFunc(func(entries ...*es.Entry) {
+			for _, entry := range entries {
+				nacked = append(nacked, entry.Event.AggregateID())
+			}
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	_ = b.Publish(context.Background(), "customers", event.NewIntegrationEvent("created", "3"), event.NewIntegrationEvent("created", "4"))
+
+	rec.await(t, 3)
+	if got := rec.consumed(); len(got) != 3 || got[0] != "1" || got[1] != "2" || got[2] != "4" {
+		t.Errorf("unexpected consumed events %v", got)
+	}
+	if len(nacked) != 4 {
+		t.Errorf("expected 1 nack of event 2 and 3 of event 3, got %v", nacked)
+	}
+}
+
+func TestMemoryBroker_group(t *testing.T) {
+	b := broker.NewMemoryBroker("/test")
+	_ = b.Publish(context.Background(), "customers", event.NewIntegrationEvent("created", "1"))
+	first := &recorder{}
+	s, _ := b.Subscribe("customers", first, broker.WithGroup("projection"))
+	first.await(t, 1)
+	s.Close()
+
+	_ = b.Publish(context.Background(), "customers", event.NewIntegrationEvent("created", "2"))
+	second := &recorder{}
+	s, _ = b.Subscribe("customers", second, broker.WithGroup("projection"))
+	defer s.Close()
+	second.await(t, 1)
+	if got := second.consumed(); len(got) != 1 || got[0] != "2" {
+		t.Errorf("group didn't resume, consumed %v", got)
+	}
+}
+
+// recorder consumes events by aggregateID and fails as often as given by failures
+type recorder struct {
+	sync.Mutex
+	failures map[string]int
+	ids      []string
+}
+
+var _ consume.Consumer = (*recorder)(nil)
+
+func (r *recorder) Handle(_ *consume.Context, entries ...*es.Entry) error {
+	r.Lock()
+	defer r.Unlock()
+	for _, entry := range entries {
+		id := entry.Event.AggregateID()
+		if r.failures[id] > 0 {
+			r.failures[id]--
+			return errors.New("consumer failed")
+		}
+		r.ids = append(r.ids, id)
+	}
+	return nil
+}
+
+func (r *recorder) consumed() []string {
+	r.Lock()
+	defer r.Unlock()
+	return append([]string(nil), r.ids...)
+}
+
+func (r *recorder) await(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(r.consumed()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("consumed %d of %d events", len(r.consumed()), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,8 @@
+package broker
+
+// error codes
+const (
+	ErrDecode = iota + 9801
+	ErrPublish
+	ErrSubscribe
+)
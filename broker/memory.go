@@ -0,0 +1,164 @@
+package broker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/event"
+	"github.com/openyard/evently/query/consume"
+)
+
+var logger = evently.Logger("broker")
+
+var _ Publisher = (*MemoryBroker)(nil)
+var _ Subscriber = (*MemoryBroker)(nil)
+
+// MemoryBroker keeps the messages of all topics in memory. Each subscription receives
+// the events of its topic in order, a failed event is redelivered before the next one.
+type MemoryBroker struct {
+	sync.Mutex
+	source string
+	topics map[string]*topic
+}
+
+type topic struct {
+	messages []*Message
+	groups   map[string]int
+	subs     map[*memorySubscription]struct{}
+}
+
+// NewMemoryBroker returns an empty MemoryBroker publishing events from the given source
+func NewMemoryBroker(source string) *MemoryBroker {
+	return &MemoryBroker{source: source, topics: make(map[string]*topic)}
+}
+
+func (b *MemoryBroker) Publish(_ context.Context, name string, events ...*event.Event) error {
+	b.Lock()
+	defer b.Unlock()
+	t := b.topic(name)
+	for _, e := range events {
+		t.messages = append(t.messages, NewMessage(name, b.source, e))
+	}
+	for s := range t.subs {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(name string, consumer consume.Consumer, opts ...DeliveryOption) (Subscription, error) {
+	b.Lock()
+	defer b.Unlock()
+	s := &memorySubscription{
+		broker:   b,
+		topic:    name,
+		consumer: consumer,
+		delivery: NewDelivery(opts...),
+		wake:     make(chan struct{}, 1),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	t := b.topic(name)
+	s.offset = t.groups[s.delivery.Group]
+	t.subs[s] = struct{}{}
+	go s.run()
+	return s, nil
+}
+
+// topic returns the topic with the given name. Must be called locked.
+func (b *MemoryBroker) topic(name string) *topic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &topic{groups: make(map[string]int), subs: make(map[*memorySubscription]struct{})}
+		b.topics[name] = t
+	}
+	return t
+}
+
+// next returns the message at the offset of the subscription or nil
+func (b *MemoryBroker) next(s *memorySubscription) *Message {
+	b.Lock()
+	defer b.Unlock()
+	t := b.topics[s.topic]
+	if s.offset >= len(t.messages) {
+		return nil
+	}
+	return t.messages[s.offset]
+}
+
+// commit moves the offset of the subscription and its group behind the current message
+func (b *MemoryBroker) commit(s *memorySubscription) {
+	b.Lock()
+	defer b.Unlock()
+	s.offset++
+	if s.delivery.Group != "" {
+		b.topics[s.topic].groups[s.delivery.Group] = s.offset
+	}
+}
+
+type memorySubscription struct {
+	broker    *MemoryBroker
+	topic     string
+	consumer  consume.Consumer
+	delivery  *Delivery
+	offset    int
+	wake      chan struct{}
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+}
+
+func (s *memorySubscription) Close() {
+	s.closeOnce.Do(func() {
+		s.cancel()
+		s.broker.Lock()
+		defer s.broker.Unlock()
+		delete(s.broker.topics[s.topic].subs, s)
+	})
+}
+
+func (s *memorySubscription) run() {
+	for {
+		msg := s.broker.next(s)
+		if msg == nil {
+			select {
+			case <-s.wake:
+				continue
+			case <-s.ctx.Done():
+				return
+			}
+		}
+		if !s.deliver(msg) {
+			return
+		}
+		s.broker.commit(s)
+	}
+}
+
+// deliver delivers the message until it's acked or dropped and reports false if the
+// subscription was closed in between
+func (s *memorySubscription) deliver(msg *Message) bool {
+	e, err := msg.Event()
+	if err != nil {
+		logger.Error("drop undecodable message", evently.LogTopic, s.topic, evently.Err(err))
+		return true
+	}
+	entry := &es.Entry{GlobalPos: uint64(s.offset), Stream: s.topic, Event: e}
+	for delivery := 1; ; delivery++ {
+		if s.ctx.Err() != nil {
+			return false
+		}
+		if s.delivery.Consume(s.ctx, s.consumer, entry, delivery) != Redeliver {
+			return true
+		}
+		select {
+		case <-time.After(s.delivery.RedeliveryDelay):
+		case <-s.ctx.Done():
+			return false
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package broker
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/event"
+)
+
+// CloudEvents attributes of a Message
+const (
+	SpecVersion = "1.0"
+
+	HeaderSpecVersion = "ce-specversion"
+	HeaderID          = "ce-id"
+	HeaderSource      = "ce-source"
+	HeaderType        = "ce-type"
+	HeaderSubject     = "ce-subject"
+	HeaderTime        = "ce-time"
+	HeaderContentType = "content-type"
+	// HeaderKind is the extension attribute holding the event.Type
+	HeaderKind = "ce-eventlykind"
+	// HeaderRecordedTime is the extension attribute holding the time the event was recorded
+	HeaderRecordedTime = "ce-eventlyrecordedtime"
+	// HeaderMetadata is the extension attribute holding the URL encoded metadata of the event
+	HeaderMetadata = "ce-eventlymetadata"
+)
+
+// Message is an event as CloudEvent in binary content mode: the attributes are headers
+// prefixed with "ce-", the payload of the event is the data
+type Message struct {
+	Topic   string
+	Headers map[string]string
+	Data    []byte
+}
+
+// NewMessage returns the event as Message to the topic. The source identifies the
+// context the event happened in, e.g. the URI of the publishing service.
+func NewMessage(topic, source string, e *event.Event) *Message {
+	headers := map[string]string{
+		HeaderSpecVersion: SpecVersion,
+		HeaderID:          e.ID(),
+		HeaderSource:      source,
+		HeaderType:        e.Name(),
+		HeaderSubject:     e.AggregateID(),
+		HeaderTime:        e.OccurredAt().Format(time.RFC3339Nano),
+		HeaderKind:        string(e.Kind()),
+		HeaderContentType: "application/octet-stream",
+	}
+	if json.Valid(e.Payload()) {
+		headers[HeaderContentType] = "application/json"
+	}
+	if !e.RecordedAt().IsZero() {
+		headers[HeaderRecordedTime] = e.RecordedAt().Format(time.RFC3339Nano)
+	}
+	if len(e.Metadata()) > 0 {
+		metadata := url.Values{}
+		for k, v := range e.Metadata() {
+			metadata.Set(k, v)
+		}
+		headers[HeaderMetadata] = metadata.Encode()
+	}
+	return &Message{Topic: topic, Headers: headers, Data: e.Payload()}
+}
+
+// Event returns the event of the message
+func (m *Message) Event() (*event.Event, error) {
+	if m.Headers[HeaderSpecVersion] != SpecVersion {
+		return nil, evently.Errorf(ErrDecode, "ErrDecode", "unsupported specversion %q", m.Headers[HeaderSpecVersion])
+	}
+	if m.Headers[HeaderID] == "" || m.Headers[HeaderType] == "" {
+		return nil, evently.Errorf(ErrDecode, "ErrDecode", "missing id or type")
+	}
+	occurredAt, err := time.Parse(time.RFC3339Nano, m.Headers[HeaderTime])
+	if err != nil {
+		return nil, evently.Errorf(ErrDecode, "ErrDecode", "event %s: invalid time", m.Headers[HeaderID]).CausedBy(err)
+	}
+	kind := event.Type(m.Headers[HeaderKind])
+	if kind != event.IntegrationEvent {
+		kind = event.DomainEvent
+	}
+	opts := []event.Option{event.WithID(m.Headers[HeaderID]), event.WithEventType(kind), event.WithPayload(m.Data)}
+	if encoded, ok := m.Headers[HeaderMetadata]; ok {
+		values, err := url.ParseQuery(encoded)
+		if err != nil {
+			return nil, evently.Errorf(ErrDecode, "ErrDecode", "event %s: invalid metadata", m.Headers[HeaderID]).CausedBy(err)
+		}
+		metadata := make(map[string]string, len(values))
+		for k := range values {
+			metadata[k] = values.Get(k)
+		}
+		opts = append(opts, event.WithMetadata(metadata))
+	}
+	e := event.NewEventAt(m.Headers[HeaderType], m.Headers[HeaderSubject], occurredAt, opts...)
+	if recorded, ok := m.Headers[HeaderRecordedTime]; ok {
+		recordedAt, err := time.Parse(time.RFC3339Nano, recorded)
+		if err != nil {
+			return nil, evently.Errorf(ErrDecode, "ErrDecode", "event %s: invalid recorded time", m.Headers[HeaderID]).CausedBy(err)
+		}
+		e = e.Record(recordedAt)
+	}
+	return e, nil
+}
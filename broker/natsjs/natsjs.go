@@ -0,0 +1,151 @@
+// Package natsjs implements the broker interfaces on NATS JetStream. Topics are subjects,
+// which must be bound to a JetStream stream by the operator. It's a module of its own, so
+// importers of evently don't depend on NATS.
+package natsjs
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/broker"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/event"
+	"github.com/openyard/evently/query/consume"
+)
+
+var _ broker.Publisher = (*Broker)(nil)
+var _ broker.Subscriber = (*Broker)(nil)
+
+type Option func(b *Broker)
+
+// Broker publishes events as CloudEvents to JetStream and subscribes to them with
+// consumers acknowledging explicitly. Events are published with their ID as message ID,
+// so JetStream drops duplicates within the duplicate window of the stream.
+type Broker struct {
+	js     jetstream.JetStream
+	source string
+	logger *slog.Logger
+}
+
+// NewBroker returns a Broker using the given JetStream context
+func NewBroker(js jetstream.JetStream, opts ...Option) *Broker {
+	b := &Broker{js: js, source: "evently", logger: evently.Logger("broker")}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// WithSource sets the CloudEvents source of published events, default is "evently"
+func WithSource(source string) Option {
+	return func(b *Broker) {
+		b.source = source
+	}
+}
+
+// WithLogger sets the logger, default is the evently logger of component "broker"
+func WithLogger(logger *slog.Logger) Option {
+	return func(b *Broker) {
+		b.logger = logger
+	}
+}
+
+func (b *Broker) Publish(ctx context.Context, topic string, events ...*event.Event) error {
+	for _, e := range events {
+		msg := broker.NewMessage(topic, b.source, e)
+		natsMsg := nats.NewMsg(topic)
+		natsMsg.Data = msg.Data
+		for k, v := range msg.Headers {
+			natsMsg.Header.Set(k, v)
+		}
+		if _, err := b.js.PublishMsg(ctx, natsMsg, jetstream.WithMsgID(e.ID())); err != nil {
+			return evently.Errorf(broker.ErrPublish, "ErrPublish", "event %s to %q", e.ID(), topic).CausedBy(err)
+		}
+	}
+	return nil
+}
+
+// Subscribe creates or updates a JetStream consumer of the stream bound to the topic.
+// A group subscribes as durable consumer named like the group. The consumer has one
+// message pending at a time, so events are delivered in order and a failed event is
+// redelivered by JetStream after the redelivery delay before the next one. A dropped
+// event is terminated.
+func (b *Broker) Subscribe(topic string, consumer consume.Consumer, opts ...broker.DeliveryOption) (broker.Subscription, error) {
+	d := broker.NewDelivery(opts...)
+	ctx := context.Background()
+	stream, err := b.js.StreamNameBySubject(ctx, topic)
+	if err != nil {
+		return nil, evently.Errorf(broker.ErrSubscribe, "ErrSubscribe", "no stream bound to %q", topic).CausedBy(err)
+	}
+	cfg := jetstream.ConsumerConfig{
+		Durable:       d.Group,
+		FilterSubject: topic,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		MaxAckPending: 1,
+		MaxDeliver:    d.MaxDeliveries,
+	}
+	if cfg.MaxDeliver == 0 {
+		cfg.MaxDeliver = -1
+	}
+	c, err := b.js.CreateOrUpdateConsumer(ctx, stream, cfg)
+	if err != nil {
+		return nil, evently.Errorf(broker.ErrSubscribe, "ErrSubscribe", "consumer of %q", topic).CausedBy(err)
+	}
+	s := &subscription{}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.consume, err = c.Consume(func(msg jetstream.Msg) {
+		b.handle(s.ctx, topic, consumer, d, msg)
+	})
+	if err != nil {
+		s.cancel()
+		return nil, evently.Errorf(broker.ErrSubscribe, "ErrSubscribe", "consume %q", topic).CausedBy(err)
+	}
+	return s, nil
+}
+
+// handle passes the message to the consumer and acknowledges it depending on the outcome
+func (b *Broker) handle(ctx context.Context, topic string, consumer consume.Consumer, d *broker.Delivery, msg jetstream.Msg) {
+	md, err := msg.Metadata()
+	if err != nil {
+		b.logger.Error("terminate message without metadata", evently.LogTopic, topic, evently.Err(err))
+		_ = msg.Term()
+		return
+	}
+	headers := make(map[string]string, len(msg.Headers()))
+	for k := range msg.Headers() {
+		headers[k] = msg.Headers().Get(k)
+	}
+	e, err := (&broker.Message{Topic: topic, Headers: headers, Data: msg.Data()}).Event()
+	if err != nil {
+		b.logger.Error("terminate undecodable message", evently.LogTopic, topic, evently.LogGlobalPos, md.Sequence.Stream, evently.Err(err))
+		_ = msg.Term()
+		return
+	}
+	entry := &es.Entry{GlobalPos: md.Sequence.Stream, Stream: topic, Event: e}
+	switch d.Consume(ctx, consumer, entry, int(md.NumDelivered)) {
+	case broker.Acked:
+		err = msg.Ack()
+	case broker.Redeliver:
+		err = msg.NakWithDelay(d.RedeliveryDelay)
+	case broker.Dropped:
+		err = msg.Term()
+	}
+	if err != nil {
+		b.logger.Error("acknowledge message", evently.LogTopic, topic, evently.LogGlobalPos, md.Sequence.Stream,
+			evently.LogStream, entry.Stream, evently.Err(err))
+	}
+}
+
+type subscription struct {
+	consume jetstream.ConsumeContext
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+func (s *subscription) Close() {
+	s.consume.Stop()
+	s.cancel()
+}
@@ -0,0 +1,161 @@
+package natsjs_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/openyard/evently/broker"
+	"github.com/openyard/evently/broker/natsjs"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/event"
+	"github.com/openyard/evently/query/consume"
+)
+
+func TestBroker(t *testing.T) {
+	js := runJetStream(t)
+	b := natsjs.NewBroker(js, natsjs.WithSource("/customers"))
+	ctx := context.Background()
+	created := event.NewIntegrationEvent("customer-created", "1", event.WithPayload([]byte(`{"name":"Jane"}`)))
+	if err := b.Publish(ctx, "events.customers", created, created); err != nil {
+		t.Fatal(err)
+	}
+	_ = b.Publish(ctx, "events.customers", event.NewIntegrationEvent("customer-blocked", "1"))
+
+	var mu sync.Mutex
+	var consumed, acked []*es.Entry
+	failed := false
+	s, err := b.Subscribe("events.customers", consume.ConsumerFunc(func(_ *consume.Context, entries ...*es.Entry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if entries[0].Event.Name() == "customer-blocked" && !failed {
+			failed = true
+			return errors.New("consumer failed")
+		}
+		consumed = append(consumed, entries...)
+		return nil
+	}), broker.WithGroup("projection"), broker.WithRedeliveryDelay(10*time.Millisecond),
+		broker.WithAckFunc(func(entries ...*es.Entry) {
+			mu.Lock()
+			defer mu.Unlock()
+			acked = append(acked, entries...)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := len(acked)
+		mu.Unlock()
+		if n >= 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond) // catch duplicates
+	mu.Lock()
+	defer mu.Unlock()
+	if len(consumed) != 2 || len(acked) != 2 {
+		t.Fatalf("expected 2 consumed and acked events, got %d and %d", len(consumed), len(acked))
+	}
+	first, second := consumed[0], consumed[1]
+	if first.Event.ID() != created.ID() || first.Stream != "events.customers" || string(first.Event.Payload()) != `{"name":"Jane"}` ||
+		first.Event.Kind() != event.IntegrationEvent || !first.Event.OccurredAt().Equal(created.OccurredAt()) {
+		t.Errorf("unexpected first entry %+v", first)
+	}
+	if second.Event.Name() != "customer-blocked" || second.GlobalPos <= first.GlobalPos {
+		t.Errorf("unexpected redelivered entry %+v", second)
+	}
+}
+
+func TestBroker_order(t *testing.T) {
+	b := natsjs.NewBroker(runJetStream(t))
+	var published []string
+	for i := 0; i < 5; i++ {
+		e := event.NewIntegrationEvent("customer-updated", "1")
+		if err := b.Publish(context.Background(), "events.customers", e); err != nil {
+			t.Fatal(err)
+		}
+		published = append(published, e.ID())
+	}
+
+	var mu sync.Mutex
+	var consumed []string
+	failed := false
+	s, err := b.Subscribe("events.customers", consume.ConsumerFunc(func(_ *consume.Context, entries ...*es.Entry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if entries[0].Event.ID() == published[1] && !failed {
+			failed = true
+			return errors.New("consumer failed")
+		}
+		for _, entry := range entries {
+			consumed = append(consumed, entry.Event.ID())
+		}
+		return nil
+	}), broker.WithRedeliveryDelay(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := len(consumed)
+		mu.Unlock()
+		if n >= len(published) || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(consumed, published) {
+		t.Errorf("expected events consumed in order %v, got %v", published, consumed)
+	}
+}
+
+func TestBroker_unboundTopic(t *testing.T) {
+	b := natsjs.NewBroker(runJetStream(t))
+	_, err := b.Subscribe("unbound", consume.ConsumerFunc(func(_ *consume.Context, _ ...*es.Entry) error { return nil }))
+	if err == nil {
+		t.Error("expected subscribing an unbound topic to fail")
+	}
+}
+
+// runJetStream runs an embedded NATS server with JetStream and a stream bound to "events.>"
+func runJetStream(t *testing.T) jetstream.JetStream {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, JetStream: true, StoreDir: t.TempDir(), NoLog: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	t.Cleanup(srv.Shutdown)
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server not ready")
+	}
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(nc.Close)
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := js.CreateStream(context.Background(), jetstream.StreamConfig{Name: "EVENTS", Subjects: []string{"events.>"}}); err != nil {
+		t.Fatal(err)
+	}
+	return js
+}
@@ -0,0 +1,143 @@
+// Command evently-jsonl exports, imports, verifies and filters event store exports in
+// JSON Lines. Export and import use the event store served by eshttp at the given URL.
+//
+// Usage:
+//
+//	evently-jsonl export -url url [-stream name]... [-from time] [-to time] [file]
+//	evently-jsonl import -url url [-stream name]... [-from time] [-to time] [file]
+//	evently-jsonl verify [-stream name]... [-from time] [-to time] [file]
+//	evently-jsonl filter [-stream name]... [-from time] [-to time] [file]
+//
+// Without a file export and filter write to stdout, the others read from stdin.
+// Import fails if a stream of the export already has events in the store.
+// Times are given in RFC 3339.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/openyard/evently/command/es/eshttp"
+	"github.com/openyard/evently/command/es/jsonl"
+	"github.com/openyard/evently/pkg/timeutil"
+)
+
+type streams []string
+
+func (s *streams) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *streams) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	if err := run(os.Args[1], os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "evently-jsonl: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: evently-jsonl export|import|verify|filter [-url url] [-stream name]... [-from time] [-to time] [file]")
+	os.Exit(2)
+}
+
+func run(cmd string, args []string) error {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	var selected streams
+	fs.Var(&selected, "stream", "select the given stream (repeatable)")
+	from := fs.String("from", "", "select events occurred at or after the given time")
+	to := fs.String("to", "", "select events occurred at or before the given time")
+	baseURL := fs.String("url", "", "base URL of the event store to export from or import into")
+	_ = fs.Parse(args)
+
+	opts, err := options(selected, *from, *to)
+	if err != nil {
+		return err
+	}
+	var result *jsonl.Result
+	switch cmd {
+	case "export":
+		if *baseURL == "" {
+			usage()
+		}
+		out := io.Writer(os.Stdout)
+		if fs.NArg() > 0 {
+			f, err := os.Create(fs.Arg(0))
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+		result, err = jsonl.Export(out, eshttp.NewClient(*baseURL), opts...)
+	case "import", "verify", "filter":
+		in := io.Reader(os.Stdin)
+		if fs.NArg() > 0 {
+			f, err := os.Open(fs.Arg(0))
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+		switch cmd {
+		case "import":
+			if *baseURL == "" {
+				usage()
+			}
+			result, err = jsonl.Import(in, eshttp.NewClient(*baseURL), opts...)
+		case "verify":
+			result, err = jsonl.Verify(in, opts...)
+		default:
+			result, err = jsonl.Copy(os.Stdout, in, opts...)
+		}
+	default:
+		usage()
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d entries in %d streams\n", result.Entries, len(result.Streams))
+	return nil
+}
+
+func options(selected streams, from, to string) ([]jsonl.Option, error) {
+	var opts []jsonl.Option
+	if len(selected) > 0 {
+		opts = append(opts, jsonl.WithStreams(selected...))
+	}
+	if from == "" && to == "" {
+		return opts, nil
+	}
+	var start, end time.Time
+	var err error
+	if from != "" {
+		if start, err = time.Parse(time.RFC3339Nano, from); err != nil {
+			return nil, err
+		}
+	}
+	if to != "" {
+		if end, err = time.Parse(time.RFC3339Nano, to); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case to == "":
+		return append(opts, jsonl.WithTimeRange(timeutil.StartingOn(start))), nil
+	case from == "":
+		return append(opts, jsonl.WithTimeRange(timeutil.UpTo(end))), nil
+	default:
+		return append(opts, jsonl.WithTimeRange(timeutil.NewTimeRange(start, end))), nil
+	}
+}
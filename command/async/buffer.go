@@ -0,0 +1,176 @@
+package async
+
+import (
+	"slices"
+	"sync"
+)
+
+// Priority of a command in the queue. Queued commands of higher priority are executed
+// first, commands of the same priority in the order they were sent.
+type Priority int
+
+const (
+	// PriorityBulk is for commands executed when there's nothing more important to do
+	PriorityBulk Priority = iota - 1
+	// PriorityNormal is the default priority of commands
+	PriorityNormal
+	// PriorityCritical is for commands that bypass normal and bulk traffic
+	PriorityCritical
+)
+
+// Overflow decides what happens to a command sent to a full queue
+type Overflow int
+
+const (
+	// Block waits until there's space in the queue or the command is done
+	Block Overflow = iota
+	// Reject fails the command with ErrQueueFull
+	Reject
+	// DropOldest fails the oldest queued command of the same or a lower priority with
+	// ErrQueueFull to make space for the command
+	DropOldest
+)
+
+// buffer holds the jobs waiting for a worker by priority. A full buffer makes space for
+// a job by dropping the oldest job of a lower priority.
+type buffer struct {
+	sync.Mutex
+	capacity int // 0 is unbounded
+	size     int
+	levels   []*level // by descending priority
+
+	ready chan struct{} // signals a queued job
+	space chan struct{} // signals free capacity
+}
+
+// level holds the jobs of a priority in arrival order
+type level struct {
+	priority Priority
+	jobs     []*job
+}
+
+func newBuffer(capacity int) *buffer {
+	return &buffer{
+		capacity: capacity,
+		ready:    make(chan struct{}, 1),
+		space:    make(chan struct{}, 1),
+	}
+}
+
+// push adds the job unless the buffer is full and there's no job to drop for it. It
+// returns the dropped job, if any.
+func (b *buffer) push(j *job, dropOldest bool) (dropped *job, ok bool) {
+	b.Lock()
+	defer b.Unlock()
+	if b.full() {
+		if dropped = b.drop(j.priority, dropOldest); dropped == nil {
+			return nil, false
+		}
+	} else {
+		b.size++
+	}
+	l := b.level(j.priority)
+	l.jobs = append(l.jobs, j)
+	signal(b.ready)
+	if !b.full() {
+		signal(b.space)
+	}
+	return dropped, true
+}
+
+// pop removes the next job, it returns false if the buffer is empty
+func (b *buffer) pop() (*job, bool) {
+	b.Lock()
+	defer b.Unlock()
+	for _, l := range b.levels {
+		if len(l.jobs) == 0 {
+			continue
+		}
+		j := l.jobs[0]
+		l.jobs[0] = nil
+		l.jobs = l.jobs[1:]
+		b.size--
+		if b.size > 0 {
+			signal(b.ready)
+		}
+		signal(b.space)
+		return j, true
+	}
+	return nil, false
+}
+
+// remove removes the given job, it returns false if the job isn't queued
+func (b *buffer) remove(j *job) bool {
+	b.Lock()
+	defer b.Unlock()
+	for _, l := range b.levels {
+		if i := slices.Index(l.jobs, j); i >= 0 {
+			l.jobs = slices.Delete(l.jobs, i, i+1)
+			b.size--
+			signal(b.space)
+			return true
+		}
+	}
+	return false
+}
+
+// drain removes and returns all jobs
+func (b *buffer) drain() []*job {
+	b.Lock()
+	defer b.Unlock()
+	var jobs []*job
+	for _, l := range b.levels {
+		jobs = append(jobs, l.jobs...)
+		l.jobs = nil
+	}
+	b.size = 0
+	return jobs
+}
+
+// len returns the number of queued jobs
+func (b *buffer) len() int {
+	b.Lock()
+	defer b.Unlock()
+	return b.size
+}
+
+func (b *buffer) full() bool {
+	return b.capacity > 0 && b.size >= b.capacity
+}
+
+// drop removes the oldest job of the lowest priority below the given one, or of the
+// same priority with dropOldest
+func (b *buffer) drop(priority Priority, dropOldest bool) *job {
+	for i := len(b.levels) - 1; i >= 0; i-- {
+		l := b.levels[i]
+		if l.priority > priority || (l.priority == priority && !dropOldest) {
+			return nil
+		}
+		if len(l.jobs) > 0 {
+			j := l.jobs[0]
+			l.jobs[0] = nil
+			l.jobs = l.jobs[1:]
+			return j
+		}
+	}
+	return nil
+}
+
+// level returns the level of the priority, it's added if missing
+func (b *buffer) level(priority Priority) *level {
+	i, found := slices.BinarySearchFunc(b.levels, priority, func(l *level, p Priority) int {
+		return int(p) - int(l.priority)
+	})
+	if !found {
+		b.levels = slices.Insert(b.levels, i, &level{priority: priority})
+	}
+	return b.levels[i]
+}
+
+// signal notifies a waiting receiver, if any
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -1,16 +1,20 @@
 package async
 
 import (
-	"log"
-	"os"
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/openyard/evently"
 	"github.com/openyard/evently/command"
+	"github.com/openyard/evently/event"
 )
 
-const defaultQueueTimeout = 400 * time.Millisecond
+const defaultPartitionDepth = 64
 
 // CommandHandlers represents a map of CommandHandleFunc
 type CommandHandlers map[string]command.HandleFunc
@@ -21,78 +25,463 @@ type QueueOption func(q *CommandQueue)
 type CommandQueue struct {
 	sync.RWMutex
 
-	commandHandler command.HandleFunc
-	workerPool     chan chan *command.Command
-	timeout        time.Duration
+	bus        *command.Bus
+	pool       *buffer
+	capacity   int
+	overflow   Overflow
+	partitions []*buffer
+	partitionN int
+	depth      int
+	priorities map[string]Priority
+	limit      *limiter
+	limits     map[string]*limiter
+	middleware command.Middleware
+	dedup      *command.Deduplicator
 
-	logger *log.Logger
+	closed  bool
+	quit    chan struct{}
+	pending sync.WaitGroup // accepted, not completed jobs
+	workers sync.WaitGroup
+	alive   atomic.Int64
+	busy    atomic.Int64
+
+	logger *slog.Logger
+}
+
+// Health of the workers of a CommandQueue
+type Health struct {
+	// Workers is the number of running workers
+	Workers int
+	// Busy is the number of workers executing a command
+	Busy int
+	// Queued is the number of commands waiting for a worker
+	Queued int
 }
 
-// NewCommandQueue with max Workers handles incoming commands
-// asynchronous with a default timeout of 400ms
+// job is a command submitted to a worker
+type job struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stop     func() bool // stops removing the job from its buffer once ctx is done
+	command  *command.Command
+	priority Priority
+	future   *command.Future
+}
+
+// dequeued stops watching the context of the job taken out of its buffer
+func (j *job) dequeued() {
+	if j.stop != nil {
+		j.stop()
+	}
+}
+
+// complete sets the result of the job and releases its context
+func (j *job) complete(events []*event.Event, version uint64, err error) {
+	j.completeOutcome(events, command.NewOutcome(j.command, events, version, err))
+}
+
+// completeOutcome sets the outcome of the job and releases its context
+func (j *job) completeOutcome(events []*event.Event, outcome *command.Outcome) {
+	j.future.CompleteOutcome(events, outcome)
+	j.cancel()
+}
+
+// NewCommandQueue with max Workers handles incoming commands asynchronous. Commands are
+// executed until their deadline, see command.WithDeadline.
 func NewCommandQueue(maxWorkers int, opts ...QueueOption) *CommandQueue {
 	queue := &CommandQueue{
-		workerPool: make(chan chan *command.Command, maxWorkers),
-		timeout:    defaultQueueTimeout,
-		logger:     log.New(log.Writer(), log.Prefix(), log.Flags()),
+		bus:        command.NewBus(),
+		priorities: make(map[string]Priority),
+		limits:     make(map[string]*limiter),
+		middleware: command.Chain(),
+		depth:      defaultPartitionDepth,
+		quit:       make(chan struct{}),
+		logger:     evently.Logger("async"),
 	}
 	for _, opt := range opts {
 		opt(queue)
 	}
+	if queue.partitionN > 0 {
+		queue.startPartitions()
+		return queue
+	}
 	queue.start(maxWorkers)
 	return queue
 }
 
-// WithQueueTimeout sets the given duration as timeout in the command queue.
-// If the execution of the command lasts longer than the given duration, the
-// command will fail with command.ErrTimeout.
-// The default timeout is 400ms.
-func WithQueueTimeout(d time.Duration) QueueOption {
+// WithPartitions processes the commands of an aggregate in order. Commands are assigned
+// by the hash of their AggregateID to one of the given number of partitions, each with
+// its own worker. The number of workers of NewCommandQueue is ignored.
+func WithPartitions(partitions int) QueueOption {
+	return func(q *CommandQueue) {
+		q.partitionN = partitions
+	}
+}
+
+// WithPartitionDepth sets the number of commands a partition queues, default is 64. A
+// full partition handles commands like a full queue, see WithOverflow.
+func WithPartitionDepth(depth int) QueueOption {
+	return func(q *CommandQueue) {
+		q.depth = depth
+	}
+}
+
+// WithCapacity limits the number of commands waiting for a worker, default is unbounded.
+// With partitions, WithPartitionDepth applies instead.
+func WithCapacity(capacity int) QueueOption {
+	return func(q *CommandQueue) {
+		q.capacity = capacity
+	}
+}
+
+// WithOverflow sets what happens to a command sent to a full queue, default is Block. A
+// command of higher priority always makes space by dropping the oldest command of the
+// lowest priority queued.
+func WithOverflow(overflow Overflow) QueueOption {
+	return func(q *CommandQueue) {
+		q.overflow = overflow
+	}
+}
+
+// WithPriority sets the priority of the given commands, default is PriorityNormal. With
+// partitions, commands of an aggregate are in order only if they have the same priority.
+func WithPriority(priority Priority, commands ...string) QueueOption {
+	return func(q *CommandQueue) {
+		for _, c := range commands {
+			q.priorities[c] = priority
+		}
+	}
+}
+
+// WithRateLimit limits the commands sent to the queue to perSecond with bursts up to
+// burst commands. A command exceeding the limit waits for its turn, it fails with
+// ErrRateLimited if it would be done before. A rate of 0 allows burst commands only.
+func WithRateLimit(perSecond float64, burst int) QueueOption {
+	return func(q *CommandQueue) {
+		q.limit = newLimiter(perSecond, burst)
+	}
+}
+
+// WithCommandRateLimit limits the given command like WithRateLimit, in addition to the
+// limit of all commands
+func WithCommandRateLimit(commandName string, perSecond float64, burst int) QueueOption {
+	return func(q *CommandQueue) {
+		q.limits[commandName] = newLimiter(perSecond, burst)
+	}
+}
+
+// WithLogger sets the logger of the queue and its workers, default is the evently logger
+// of component "async"
+func WithLogger(logger *slog.Logger) QueueOption {
 	return func(q *CommandQueue) {
-		q.timeout = d
+		q.logger = logger
 	}
 }
 
-// Register the given command.HandleFunc for 1 to n commands
+// WithMiddleware wraps the registered command.HandleFunc with the given middlewares
+func WithMiddleware(middlewares ...command.Middleware) QueueOption {
+	return func(q *CommandQueue) {
+		q.middleware = command.Chain(middlewares...)
+	}
+}
+
+// WithDeduplication handles every command ID once, a repeated command gets the outcome of
+// its first handling
+func WithDeduplication(d *command.Deduplicator) QueueOption {
+	return func(q *CommandQueue) {
+		q.dedup = d
+	}
+}
+
+// Register the given command.HandleFunc for 1 to n commands. Without commands, it
+// handles all commands without own handler, see command.Bus.
 func (q *CommandQueue) Register(ch command.HandleFunc, commands ...string) {
-	q.sync(func() {
-		//for _, c := range commands {
-		q.commandHandler = ch
-		//}
-	})
+	q.bus.Register(ch, commands...)
+}
+
+// RegisterExecuteFunc registers the given command.ExecuteFunc for 1 to n commands like
+// Register. Its events and version are the result of the commands.
+func (q *CommandQueue) RegisterExecuteFunc(ef command.ExecuteFunc, commands ...string) {
+	q.bus.RegisterExecuteFunc(ef, commands...)
+}
+
+// RegisterService registers the service for all commands of its domain model
+func (q *CommandQueue) RegisterService(cs *command.Service) {
+	q.bus.RegisterService(cs)
 }
 
 // start the CommandBus worker
 func (q *CommandQueue) start(maxWorkers int) {
+	q.pool = newBuffer(q.capacity)
 	for i := 0; i < maxWorkers; i++ {
-		newWorker(q.commandHandler, q.workerPool)
+		newWorker(q, q.pool, q.logger)
 	}
 }
 
-// Send given Command to queue
-// If the command is not
+// Send given Command to queue and waits until it's executed
 func (q *CommandQueue) Send(cmd *command.Command) error {
-	if os.Getenv("TRACE") != "" {
+	return q.SendContext(context.Background(), cmd)
+}
+
+// SendContext sends the command to the queue and waits until it's executed or ctx is done
+func (q *CommandQueue) SendContext(ctx context.Context, cmd *command.Command) error {
+	if q.logger.Enabled(ctx, slog.LevelDebug) {
 		start := time.Now()
-		defer q.logger.Printf("[%T] send cmd [%s] took [%s]\n", q, cmd.CommandName(), time.Now().Sub(start))
+		defer func() { q.logger.Debug("send command", commandAttrs(cmd, slog.Duration("took", time.Since(start)))...) }()
+	}
+	ctx, cancel := cmd.Context(ctx)
+	defer cancel()
+	_, _, err := q.Submit(ctx, cmd).Wait(ctx)
+	return err
+}
+
+// Submit sends the command to the queue and returns its pending result. The command is
+// executed until ctx or the command is done, it fails with command.ErrTimeout if no worker
+// took it by then. Submit returns once the command is queued, so commands of an aggregate
+// submitted one after the other keep their order with partitions. Rate limits and a full
+// queue may delay Submit, see WithRateLimit and WithOverflow. A queue shut down fails all
+// commands with ErrClosed.
+func (q *CommandQueue) Submit(ctx context.Context, cmd *command.Command) *command.Future {
+	j := &job{command: cmd, priority: q.priorities[cmd.CommandName()], future: command.NewFuture(cmd)}
+	j.ctx, j.cancel = cmd.Context(ctx)
+	q.RLock()
+	closed := q.closed
+	q.RUnlock()
+	if closed {
+		j.complete(nil, 0, q.errClosed(cmd))
+		return j.future
+	}
+	release, err := q.throttle(j)
+	if err != nil {
+		j.complete(nil, 0, err)
+		return j.future
+	}
+	q.RLock()
+	if q.closed {
+		q.RUnlock()
+		release()
+		j.complete(nil, 0, q.errClosed(cmd))
+		return j.future
+	}
+	q.pending.Add(1)
+	err = q.enqueue(j)
+	q.RUnlock()
+	if err != nil {
+		release()
+		q.reject(j, err)
+	}
+	return j.future
+}
+
+// enqueue adds the job to the buffer of its partition or the pool. It's called with the
+// read lock held and releases it while waiting for space, so it doesn't block Shutdown.
+func (q *CommandQueue) enqueue(j *job) error {
+	buf := q.pool
+	if q.partitions != nil {
+		buf = q.partitions[q.partition(j.command.AggregateID())]
 	}
-	go func(c *command.Command) {
-		workerJobQueue := <-q.workerPool
-		workerJobQueue <- c
-	}(cmd)
+	j.stop = context.AfterFunc(j.ctx, func() {
+		if buf.remove(j) {
+			q.reject(j, command.TimedOut(j.command, j.ctx.Err()))
+		}
+	})
+	for {
+		dropped, ok := buf.push(j, q.overflow == DropOldest)
+		if dropped != nil {
+			q.logger.Warn("command dropped", commandAttrs(dropped.command)...)
+			q.reject(dropped, q.errQueueFull(dropped.command))
+		}
+		if ok {
+			return nil
+		}
+		if q.overflow != Block {
+			return q.errQueueFull(j.command)
+		}
+		q.RUnlock()
+		spaced := false
+		select {
+		case <-buf.space:
+			spaced = true
+		case <-j.ctx.Done():
+		case <-q.quit:
+		}
+		q.RLock()
+		var err error
+		select {
+		case <-q.quit:
+			err = q.errClosed(j.command)
+		default:
+			if ctxErr := j.ctx.Err(); ctxErr != nil {
+				err = command.TimedOut(j.command, ctxErr)
+			}
+		}
+		if err != nil {
+			if spaced { // pass the space on to the next blocked submitter
+				signal(buf.space)
+			}
+			return err
+		}
+	}
+}
 
+// throttle waits until the rate limits of the job allow to queue it and returns the func
+// releasing the taken tokens if the job isn't queued after all. It fails with
+// ErrRateLimited without waiting if the job would be done before.
+func (q *CommandQueue) throttle(j *job) (func(), error) {
+	limiters := make([]*limiter, 0, 2)
+	if l, ok := q.limits[j.command.CommandName()]; ok {
+		limiters = append(limiters, l)
+	}
+	if q.limit != nil {
+		limiters = append(limiters, q.limit)
+	}
+	if len(limiters) == 0 {
+		return func() {}, nil
+	}
+	maxWait := time.Duration(math.MaxInt64)
+	if deadline, ok := j.ctx.Deadline(); ok {
+		maxWait = time.Until(deadline)
+	}
+	now := time.Now()
+	var wait time.Duration
+	for i, l := range limiters {
+		d, ok := l.reserve(now, maxWait)
+		if !ok {
+			for _, taken := range limiters[:i] {
+				taken.release()
+			}
+			return nil, evently.Errorf(ErrRateLimited, "ErrRateLimited", "[%T] rate limit exceeded by %q", q, j.command.CommandName())
+		}
+		wait = max(wait, d)
+	}
+	release := func() {
+		for _, l := range limiters {
+			l.release()
+		}
+	}
+	if wait == 0 {
+		return release, nil
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
 	select {
-	case <-cmd.Done():
-		return nil
-	case err := <-cmd.Error():
-		return err
-	case <-time.After(q.timeout):
-		return evently.Errorf(command.ErrTimeout, "ErrTimeout", "timeout! executing cmd [%+v]", cmd.CommandName())
+	case <-t.C:
+		return release, nil
+	case <-j.ctx.Done():
+		release()
+		return nil, command.TimedOut(j.command, j.ctx.Err())
+	case <-q.quit:
+		release()
+		return nil, q.errClosed(j.command)
 	}
 }
 
-func (q *CommandQueue) sync(f func()) {
+// Shutdown stops to accept commands, waits until all accepted commands are executed and
+// stops the workers. If ctx is done first, commands not taken by a worker yet fail with
+// ErrClosed. Shutdown returns once all workers stopped, so it waits for running commands.
+func (q *CommandQueue) Shutdown(ctx context.Context) error {
 	q.Lock()
+	if q.closed {
+		q.Unlock()
+		return nil
+	}
+	q.closed = true
+	q.Unlock()
+	drained := make(chan struct{})
+	go func() {
+		q.pending.Wait()
+		close(drained)
+	}()
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = evently.Errorf(ErrClosed, "ErrClosed", "[%T] shut down with %d queued commands", q, q.Health().Queued).CausedBy(ctx.Err())
+	}
+	close(q.quit)
+	q.workers.Wait()
+	q.Lock() // wait for commands queued meanwhile
 	defer q.Unlock()
-	f()
+	for _, buf := range q.buffers() {
+		for _, j := range buf.drain() {
+			q.reject(j, q.errClosed(j.command))
+		}
+	}
+	return err
+}
+
+// Health returns the state of the workers
+func (q *CommandQueue) Health() Health {
+	var queued int
+	for _, buf := range q.buffers() {
+		queued += buf.len()
+	}
+	return Health{Workers: int(q.alive.Load()), Busy: int(q.busy.Load()), Queued: queued}
+}
+
+// buffers returns the buffers of the pool or the partitions
+func (q *CommandQueue) buffers() []*buffer {
+	if q.partitions != nil {
+		return q.partitions
+	}
+	return []*buffer{q.pool}
+}
+
+// reject completes the accepted job with the given error
+func (q *CommandQueue) reject(j *job, err error) {
+	j.dequeued()
+	j.complete(nil, 0, err)
+	q.pending.Done()
+}
+
+// startPartitions starts a worker per partition
+func (q *CommandQueue) startPartitions() {
+	q.partitions = make([]*buffer, q.partitionN)
+	for i := range q.partitions {
+		q.partitions[i] = newBuffer(q.depth)
+		newWorker(q, q.partitions[i], q.logger.With("partition", i))
+	}
+}
+
+// partition returns the partition of the aggregate
+func (q *CommandQueue) partition(aggregateID string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(aggregateID))
+	return int(h.Sum32() % uint32(len(q.partitions)))
+}
+
+// handle routes the command to its handler wrapped with the middlewares of the queue. A
+// duplicate gets the recorded outcome without events.
+func (q *CommandQueue) handle(ctx context.Context, cmd *command.Command) (events []*event.Event, outcome *command.Outcome) {
+	process := func(c *command.Command) *command.Outcome {
+		var version uint64
+		err := q.middleware(func(c *command.Command) (err error) {
+			events, version, err = q.bus.Execute(ctx, c)
+			return err
+		})(c)
+		return command.NewOutcome(c, events, version, err)
+	}
+	if q.dedup != nil {
+		return events, q.dedup.Process(cmd, process)
+	}
+	return events, process(cmd)
+}
+
+// errClosed returns ErrClosed for the command sent to a queue shut down
+func (q *CommandQueue) errClosed(cmd *command.Command) error {
+	return evently.Errorf(ErrClosed, "ErrClosed", "[%T] shut down, can't send %q", q, cmd.CommandName())
+}
+
+// errQueueFull returns ErrQueueFull for the command rejected or dropped by a full queue
+func (q *CommandQueue) errQueueFull(cmd *command.Command) error {
+	return evently.Errorf(ErrQueueFull, "ErrQueueFull", "[%T] full, can't queue %q", q, cmd.CommandName())
+}
+
+// commandAttrs returns the log attributes of the command followed by the given ones
+func commandAttrs(cmd *command.Command, attrs ...any) []any {
+	return append([]any{
+		evently.LogCommandName, cmd.CommandName(),
+		evently.LogCommandID, cmd.CommandID(),
+		evently.LogAggregateID, cmd.AggregateID(),
+	}, attrs...)
 }
@@ -0,0 +1,376 @@
+package async_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command"
+	"github.com/openyard/evently/command/async"
+	"github.com/openyard/evently/command/es/estest"
+	"github.com/openyard/evently/example"
+)
+
+func TestCommandQueue_Submit(t *testing.T) {
+	svc := command.NewService(estest.NewTestEventStore(), new(example.CustomerFactory).Create)
+	q := async.NewCommandQueue(2)
+	q.RegisterExecuteFunc(svc.Execute)
+	birthdate, _ := time.Parse("2006-01-02", "1999-06-01")
+	onboard := example.OnboardCustomer("4711", "John Doe", birthdate, 'M')
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	events, version, err := q.Submit(ctx, onboard).Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].CausationID() != onboard.CommandID() || version != 1 {
+		t.Errorf("unexpected result %+v at version %d", events, version)
+	}
+	activate := command.New("customer/v1.activateCustomer", "4711", command.WithExpectedVersion(version))
+	if err := q.SendContext(ctx, activate); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommandQueue_deadline(t *testing.T) {
+	q := async.NewCommandQueue(1)
+	q.Register(func(*command.Command) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+	start := time.Now()
+	err := q.Send(command.New("slow", "1", command.WithTimeout(10*time.Millisecond)))
+	var e *evently.Error
+	if !errors.As(err, &e) || e.Code != command.ErrTimeout {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	if took := time.Since(start); took > 40*time.Millisecond {
+		t.Errorf("expected deadline of command respected, took %s", took)
+	}
+	if err := q.Send(command.New("expired", "1", command.WithDeadline(time.Now().Add(-time.Second)))); !errors.As(err, &e) || e.Code != command.ErrTimeout {
+		t.Errorf("expected expired command to time out, got %v", err)
+	}
+}
+
+func TestCommandQueue_WithDeduplication(t *testing.T) {
+	store := estest.NewTestEventStore()
+	svc := command.NewService(store, new(example.CustomerFactory).Create)
+	q := async.NewCommandQueue(1, async.WithDeduplication(command.NewDeduplicator(command.NewMemoryOutcomeStore())))
+	q.RegisterExecuteFunc(svc.Execute)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	activate := example.ActivateCustomer("4711")
+	first := q.Submit(ctx, activate).Outcome(ctx)
+	if first.Err != nil || first.Version != 1 || len(first.EventIDs) != 1 {
+		t.Fatalf("unexpected outcome %+v", first)
+	}
+	// the client sends the command again, e.g. after a restart
+	resent := command.New(activate.CommandName(), "4711", command.WithID(activate.CommandID()))
+	outcome := q.Submit(ctx, resent).Outcome(ctx)
+	if !outcome.Duplicate || outcome.Version != 1 || !reflect.DeepEqual(outcome.EventIDs, first.EventIDs) {
+		t.Errorf("expected original outcome, got %+v", outcome)
+	}
+	if _, version, err := q.Submit(ctx, resent).Wait(ctx); err != nil || version != 1 {
+		t.Errorf("expected version of the original outcome, got %d: %v", version, err)
+	}
+	if h, _ := store.ReadStream("4711"); len(h) != 1 {
+		t.Errorf("expected command executed once, got %d events", len(h))
+	}
+}
+
+func TestCommandQueue_routing(t *testing.T) {
+	q := async.NewCommandQueue(2)
+	var activated, blocked int
+	q.Register(func(*command.Command) error { activated++; return nil }, "activate")
+	q.Register(func(*command.Command) error { blocked++; return nil }, "block")
+	_ = q.Send(command.New("activate", "1"))
+	_ = q.Send(command.New("block", "1"))
+	if activated != 1 || blocked != 1 {
+		t.Errorf("expected each command routed to its handler, got %d and %d", activated, blocked)
+	}
+	var e *evently.Error
+	if err := q.Send(command.New("unknown", "1")); !errors.As(err, &e) || e.Code != command.ErrUnknownCommand {
+		t.Errorf("expected unknown command rejected, got %v", err)
+	}
+}
+
+func TestCommandQueue_WithPartitions(t *testing.T) {
+	q := async.NewCommandQueue(0, async.WithPartitions(4), async.WithPartitionDepth(16))
+	var mu sync.Mutex
+	var running, maxRunning int
+	handled := map[string][]string{}
+	q.Register(func(c *command.Command) error {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		running--
+		handled[c.AggregateID()] = append(handled[c.AggregateID()], string(c.Payload()))
+		return nil
+	})
+	var futures []*command.Future
+	for n := 0; n < 20; n++ {
+		for _, id := range []string{"a", "b", "c", "d", "e", "f"} {
+			c := command.New("update", id, command.WithPayload([]byte(strconv.Itoa(n))))
+			futures = append(futures, q.Submit(context.Background(), c))
+		}
+	}
+	for _, f := range futures {
+		if _, _, err := f.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for id, payloads := range handled {
+		for n, p := range payloads {
+			if p != strconv.Itoa(n) {
+				t.Fatalf("expected commands of %s in order, got %v", id, payloads)
+			}
+		}
+	}
+	if maxRunning < 2 {
+		t.Error("expected aggregates processed in parallel")
+	}
+}
+
+func TestCommandQueue_workerSurvives(t *testing.T) {
+	q := async.NewCommandQueue(1)
+	q.Register(func(*command.Command) error { panic("boom") }, "panic")
+	q.Register(func(*command.Command) error { return errors.New("failed") }, "fail")
+	q.Register(func(*command.Command) error { return nil }, "succeed")
+	var e *evently.Error
+	if err := q.Send(command.New("panic", "1")); !errors.As(err, &e) || e.Code != command.ErrPanic {
+		t.Errorf("expected panic recovered, got %v", err)
+	}
+	if err := q.Send(command.New("fail", "1")); err == nil {
+		t.Error("expected command to fail")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := q.SendContext(ctx, command.New("succeed", "1")); err != nil {
+		t.Errorf("expected worker still alive, got %v", err)
+	}
+	if h := q.Health(); h.Workers != 1 || h.Busy != 0 || h.Queued != 0 {
+		t.Errorf("unexpected health %+v", h)
+	}
+}
+
+func TestCommandQueue_Shutdown(t *testing.T) {
+	for name, opts := range map[string][]async.QueueOption{"pool": nil, "partitions": {async.WithPartitions(2)}} {
+		t.Run(name, func(t *testing.T) {
+			q := async.NewCommandQueue(2, opts...)
+			release := make(chan struct{})
+			var mu sync.Mutex
+			var handled int
+			q.Register(func(*command.Command) error {
+				<-release
+				mu.Lock()
+				defer mu.Unlock()
+				handled++
+				return nil
+			})
+			var futures []*command.Future
+			for n := 0; n < 6; n++ {
+				futures = append(futures, q.Submit(context.Background(), command.New("update", strconv.Itoa(n))))
+			}
+			time.Sleep(10 * time.Millisecond)
+			if h := q.Health(); h.Workers != 2 || h.Busy != 2 || h.Queued != 4 {
+				t.Errorf("unexpected health %+v", h)
+			}
+			done := make(chan error)
+			go func() { done <- q.Shutdown(context.Background()) }()
+			time.Sleep(10 * time.Millisecond)
+			var e *evently.Error
+			if err := q.Send(command.New("update", "7")); !errors.As(err, &e) || e.Code != async.ErrClosed {
+				t.Errorf("expected command rejected after shutdown, got %v", err)
+			}
+			close(release)
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range futures {
+				if _, _, err := f.Wait(context.Background()); err != nil {
+					t.Error(err)
+				}
+			}
+			if handled != 6 {
+				t.Errorf("expected accepted commands drained, got %d", handled)
+			}
+			if h := q.Health(); h.Workers != 0 {
+				t.Errorf("expected workers stopped, got %+v", h)
+			}
+		})
+	}
+}
+
+func TestCommandQueue_Shutdown_deadline(t *testing.T) {
+	q := async.NewCommandQueue(1)
+	release := make(chan struct{})
+	q.Register(func(*command.Command) error { <-release; return nil })
+	running := q.Submit(context.Background(), command.New("update", "1"))
+	time.Sleep(10 * time.Millisecond)
+	queued := q.Submit(context.Background(), command.New("update", "2"))
+	time.Sleep(10 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	time.AfterFunc(30*time.Millisecond, func() { close(release) })
+	var e *evently.Error
+	if err := q.Shutdown(ctx); !errors.As(err, &e) || e.Code != async.ErrClosed {
+		t.Errorf("expected shutdown to time out, got %v", err)
+	}
+	if _, _, err := running.Wait(context.Background()); err != nil {
+		t.Errorf("expected running command completed, got %v", err)
+	}
+	if _, _, err := queued.Wait(context.Background()); !errors.As(err, &e) || e.Code != async.ErrClosed {
+		t.Errorf("expected queued command rejected, got %v", err)
+	}
+}
+
+func TestCommandQueue_WithCapacity(t *testing.T) {
+	var e *evently.Error
+	submit := func(overflow async.Overflow) (*async.CommandQueue, chan struct{}, []*command.Future) {
+		q := async.NewCommandQueue(1, async.WithCapacity(2), async.WithOverflow(overflow))
+		release := make(chan struct{})
+		q.Register(func(*command.Command) error { <-release; return nil })
+		futures := []*command.Future{q.Submit(context.Background(), command.New("update", "0"))}
+		time.Sleep(10 * time.Millisecond) // worker busy with first command
+		for n := 1; n < 3; n++ {
+			futures = append(futures, q.Submit(context.Background(), command.New("update", strconv.Itoa(n))))
+		}
+		return q, release, futures
+	}
+
+	q, release, futures := submit(async.Reject)
+	if err := q.Send(command.New("update", "3")); !errors.As(err, &e) || e.Code != async.ErrQueueFull {
+		t.Errorf("expected full queue to reject command, got %v", err)
+	}
+	close(release)
+	for _, f := range futures {
+		if _, _, err := f.Wait(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}
+
+	q, release, futures = submit(async.DropOldest)
+	last := q.Submit(context.Background(), command.New("update", "3"))
+	if _, _, err := futures[1].Wait(context.Background()); !errors.As(err, &e) || e.Code != async.ErrQueueFull {
+		t.Errorf("expected oldest queued command dropped, got %v", err)
+	}
+	close(release)
+	for _, f := range []*command.Future{futures[0], futures[2], last} {
+		if _, _, err := f.Wait(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}
+
+	q, release, _ = submit(async.Block)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.SendContext(ctx, command.New("update", "3")); !errors.As(err, &e) || e.Code != command.ErrTimeout {
+		t.Errorf("expected blocked command to time out, got %v", err)
+	}
+	blocked := make(chan error)
+	go func() { blocked <- q.Send(command.New("update", "4")) }()
+	close(release)
+	if err := <-blocked; err != nil {
+		t.Errorf("expected blocked command executed, got %v", err)
+	}
+}
+
+func TestCommandQueue_WithPriority(t *testing.T) {
+	q := async.NewCommandQueue(1, async.WithCapacity(3), async.WithOverflow(async.Reject),
+		async.WithPriority(async.PriorityCritical, "critical"), async.WithPriority(async.PriorityBulk, "bulk"))
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var handled []string
+	q.Register(func(c *command.Command) error {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, c.CommandName()+c.AggregateID())
+		return nil
+	})
+	_ = q.Submit(context.Background(), command.New("normal", "0"))
+	time.Sleep(10 * time.Millisecond)
+	var futures []*command.Future
+	for _, name := range []string{"bulk", "normal", "bulk"} {
+		futures = append(futures, q.Submit(context.Background(), command.New(name, "1")))
+	}
+	futures = append(futures, q.Submit(context.Background(), command.New("critical", "1")))
+	var e *evently.Error
+	if _, _, err := futures[0].Wait(context.Background()); !errors.As(err, &e) || e.Code != async.ErrQueueFull {
+		t.Errorf("expected bulk command dropped for critical one, got %v", err)
+	}
+	close(release)
+	for _, f := range futures[1:] {
+		if _, _, err := f.Wait(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}
+	if want := []string{"normal0", "critical1", "normal1", "bulk1"}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("expected %v, got %v", want, handled)
+	}
+}
+
+func TestCommandQueue_WithRateLimit(t *testing.T) {
+	q := async.NewCommandQueue(2, async.WithRateLimit(100, 2), async.WithCommandRateLimit("report", 10, 1))
+	q.Register(func(*command.Command) error { return nil })
+	start := time.Now()
+	for n := 0; n < 4; n++ {
+		if err := q.Send(command.New("update", strconv.Itoa(n))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if took := time.Since(start); took < 15*time.Millisecond {
+		t.Errorf("expected commands beyond burst delayed, took %s", took)
+	}
+	if err := q.Send(command.New("report", "1")); err != nil {
+		t.Fatal(err)
+	}
+	var e *evently.Error
+	err := q.Send(command.New("report", "2", command.WithTimeout(10*time.Millisecond)))
+	if !errors.As(err, &e) || e.Code != async.ErrRateLimited {
+		t.Errorf("expected command rate limit exceeded, got %v", err)
+	}
+	if err := q.Send(command.New("update", "5", command.WithTimeout(50*time.Millisecond))); err != nil {
+		t.Errorf("expected other commands not limited by command rate limit, got %v", err)
+	}
+}
+
+func TestCommandQueue_WithRateLimit_zero(t *testing.T) {
+	q := async.NewCommandQueue(1, async.WithCommandRateLimit("report", 0, 2))
+	q.Register(func(*command.Command) error { return nil })
+	for n := 0; n < 2; n++ {
+		if err := q.Send(command.New("report", strconv.Itoa(n))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var e *evently.Error
+	if err := q.Send(command.New("report", "3")); !errors.As(err, &e) || e.Code != async.ErrRateLimited {
+		t.Errorf("expected commands beyond burst limited without rate, got %v", err)
+	}
+}
+
+func TestCommandQueue_WithRateLimit_cancel(t *testing.T) {
+	q := async.NewCommandQueue(1, async.WithRateLimit(10, 1))
+	q.Register(func(*command.Command) error { return nil })
+	if err := q.Send(command.New("update", "1")); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if _, _, err := q.Submit(ctx, command.New("update", "2")).Wait(context.Background()); err == nil {
+		t.Fatal("expected command canceled while throttled")
+	}
+	if err := q.Send(command.New("update", "3", command.WithTimeout(150*time.Millisecond))); err != nil {
+		t.Errorf("expected token of canceled command released, got %v", err)
+	}
+}
@@ -0,0 +1,11 @@
+package async
+
+// error codes
+const (
+	// ErrClosed thrown when a command is sent to a CommandQueue shut down
+	ErrClosed = iota + 10001
+	// ErrQueueFull thrown when a command is rejected or dropped by a full CommandQueue
+	ErrQueueFull
+	// ErrRateLimited thrown when a command would exceed a rate limit before it's done
+	ErrRateLimited
+)
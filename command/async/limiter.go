@@ -0,0 +1,52 @@
+package async
+
+import (
+	"sync"
+	"time"
+)
+
+// limiter is a token bucket holding up to burst tokens, refilled with rate tokens per
+// second. A command takes a token before it's queued. Without a positive rate, the
+// tokens aren't refilled.
+type limiter struct {
+	sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+	return &limiter{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+// reserve takes a token and returns how long to wait until it's available. It fails
+// without taking the token if that's longer than maxWait.
+func (l *limiter) reserve(now time.Time, maxWait time.Duration) (time.Duration, bool) {
+	l.Lock()
+	defer l.Unlock()
+	tokens := l.tokens
+	if !l.last.IsZero() && now.After(l.last) && l.rate > 0 {
+		tokens = min(l.burst, tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	tokens--
+	var wait time.Duration
+	if tokens < 0 {
+		if l.rate <= 0 { // never available
+			return 0, false
+		}
+		wait = time.Duration(-tokens / l.rate * float64(time.Second))
+	}
+	if wait > maxWait {
+		return 0, false
+	}
+	l.tokens, l.last = tokens, now
+	return wait, true
+}
+
+// release returns a token taken but not used
+func (l *limiter) release() {
+	l.Lock()
+	defer l.Unlock()
+	l.tokens = min(l.burst, l.tokens+1)
+}
@@ -1,49 +1,83 @@
 package async
 
 import (
-	"log"
-	"os"
+	"log/slog"
 
+	"github.com/openyard/evently"
 	"github.com/openyard/evently/command"
+	"github.com/openyard/evently/event"
 	"github.com/openyard/evently/pkg/uuid"
 )
 
+// worker executes the jobs of its channel one after the other until the queue quits.
+// Failing and panicking commands don't stop a worker.
 type worker struct {
-	id             string
-	commandHandler command.HandleFunc
-	jobChannel     chan *command.Command
-	workerPool     chan chan *command.Command
+	id    string
+	queue *CommandQueue
+	jobs  *buffer
 
-	logger *log.Logger
+	logger *slog.Logger
 }
 
-func newWorker(ch command.HandleFunc, wp chan chan *command.Command) {
+func newWorker(q *CommandQueue, jobs *buffer, logger *slog.Logger) {
 	id := uuid.NewV4().String()
 	w := &worker{
-		id:             id,
-		commandHandler: ch,
-		jobChannel:     make(chan *command.Command, len(wp)*10),
-		workerPool:     wp,
-		logger:         log.New(log.Writer(), log.Prefix(), log.Flags()),
+		id:     id,
+		queue:  q,
+		jobs:   jobs,
+		logger: logger.With("worker", id),
 	}
-	w.start()
+	q.workers.Add(1)
+	q.alive.Add(1)
+	go w.start()
 }
 
 func (w *worker) start() {
-	go func() {
-		for {
-			w.workerPool <- w.jobChannel
-
-			job := <-w.jobChannel
-			if err := w.commandHandler.Handle(job); err != nil {
-				w.logger.Printf("[%T] command execution failed: %s", w, err)
-				job.Failed(err)
+	defer w.queue.workers.Done()
+	defer w.queue.alive.Add(-1)
+	for {
+		job, ok := w.jobs.pop()
+		if !ok {
+			select {
+			case <-w.jobs.ready:
+				continue
+			case <-w.queue.quit:
 				return
 			}
-			if os.Getenv("TRACE") != "" {
-				w.logger.Printf("[%T][%s] command execution done: %s(%s) for %s", w, w.id, job.CommandName(), job.CommandID(), job.AggregateID())
-			}
-			job.Executed()
 		}
-	}()
+		job.dequeued()
+		select {
+		case <-w.queue.quit: // shut down meanwhile
+			w.queue.reject(job, w.queue.errClosed(job.command))
+		default:
+			w.execute(job)
+		}
+	}
+}
+
+// execute executes the job unless it's expired and completes it
+func (w *worker) execute(job *job) {
+	w.queue.busy.Add(1)
+	defer w.queue.busy.Add(-1)
+	defer w.queue.pending.Done()
+	if err := job.ctx.Err(); err != nil {
+		w.logger.Warn("command expired before execution", commandAttrs(job.command, evently.Err(err))...)
+		job.complete(nil, 0, command.TimedOut(job.command, err))
+		return
+	}
+	var events []*event.Event
+	var outcome *command.Outcome
+	err := command.Recover()(func(c *command.Command) error {
+		events, outcome = w.queue.handle(job.ctx, c)
+		return outcome.Err
+	})(job.command)
+	if outcome == nil { // panicked
+		outcome = command.NewOutcome(job.command, nil, 0, err)
+	}
+	job.completeOutcome(events, outcome)
+	if err != nil {
+		w.logger.Error("command execution failed", commandAttrs(job.command, evently.Err(err))...)
+		return
+	}
+	w.logger.Debug("command execution done", commandAttrs(job.command)...)
 }
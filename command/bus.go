@@ -0,0 +1,81 @@
+package command
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/event"
+)
+
+// Bus routes commands by name to the registered handlers. Handlers may be registered at
+// any time, also while commands are routed.
+type Bus struct {
+	sync.RWMutex
+	routes   map[string]ExecuteFunc
+	fallback ExecuteFunc
+}
+
+func NewBus() *Bus {
+	return &Bus{routes: make(map[string]ExecuteFunc)}
+}
+
+// Register routes the given commands to the handler. Without commands, the handler gets
+// all commands without own route. A later registration of a command replaces the former.
+func (b *Bus) Register(ch HandleFunc, commands ...string) {
+	b.RegisterExecuteFunc(func(_ context.Context, c *Command) ([]*event.Event, uint64, error) {
+		return nil, 0, ch(c)
+	}, commands...)
+}
+
+// RegisterExecuteFunc routes the given commands to the ExecuteFunc like Register
+func (b *Bus) RegisterExecuteFunc(ef ExecuteFunc, commands ...string) {
+	b.Lock()
+	defer b.Unlock()
+	if len(commands) == 0 {
+		b.fallback = ef
+		return
+	}
+	for _, c := range commands {
+		b.routes[c] = ef
+	}
+}
+
+// RegisterService routes all commands handled by the domain model of the service to it
+func (b *Bus) RegisterService(cs *Service) {
+	b.RegisterExecuteFunc(cs.Execute, cs.Commands()...)
+}
+
+// Commands returns the names of all routed commands
+func (b *Bus) Commands() []string {
+	b.RLock()
+	defer b.RUnlock()
+	commands := make([]string, 0, len(b.routes))
+	for c := range b.routes {
+		commands = append(commands, c)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// Execute passes the command to its handler, it's an ExecuteFunc. An unknown command fails
+// with ErrUnknownCommand.
+func (b *Bus) Execute(ctx context.Context, c *Command) ([]*event.Event, uint64, error) {
+	b.RLock()
+	ef, ok := b.routes[c.CommandName()]
+	if !ok {
+		ef = b.fallback
+	}
+	b.RUnlock()
+	if ef == nil {
+		return nil, 0, evently.Errorf(ErrUnknownCommand, "ErrUnknownCommand", "[%T] unknown command: %q", b, c.CommandName())
+	}
+	return ef(ctx, c)
+}
+
+// Process passes the command to its handler, it's a HandleFunc
+func (b *Bus) Process(c *Command) error {
+	_, _, err := b.Execute(context.Background(), c)
+	return err
+}
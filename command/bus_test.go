@@ -0,0 +1,54 @@
+package command_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command"
+	"github.com/openyard/evently/command/es/estest"
+	"github.com/openyard/evently/event"
+	"github.com/openyard/evently/example"
+)
+
+func TestBus(t *testing.T) {
+	store := estest.NewTestEventStore()
+	customers := command.NewService(store, new(example.CustomerFactory).Create)
+	orders := command.NewService(store, newOrder)
+	b := command.NewBus()
+	b.RegisterService(customers)
+	b.RegisterService(orders)
+	if want := []string{"customer/v1.activateCustomer", "customer/v1.blockCustomer", "customer/v1.onboardCustomer", "order/v1.placeOrder"}; !reflect.DeepEqual(b.Commands(), want) {
+		t.Errorf("unexpected routes %v", b.Commands())
+	}
+
+	events, _, err := b.Execute(context.Background(), command.New("order/v1.placeOrder", "order-1"))
+	if err != nil || len(events) != 1 || events[0].Name() != "order-placed" {
+		t.Errorf("expected order placed, got %+v: %v", events, err)
+	}
+	if err := b.Process(example.ActivateCustomer("4711")); err != nil {
+		t.Error(err)
+	}
+	var e *evently.Error
+	if err := b.Process(command.New("order/v1.cancelOrder", "order-1")); !errors.As(err, &e) || e.Code != command.ErrUnknownCommand {
+		t.Errorf("expected unknown command, got %v", err)
+	}
+
+	var fallback []string
+	b.Register(func(c *command.Command) error { fallback = append(fallback, c.CommandName()); return nil })
+	if err := b.Process(command.New("order/v1.cancelOrder", "order-1")); err != nil || len(fallback) != 1 {
+		t.Errorf("expected command routed to handler registered later, got %v", err)
+	}
+}
+
+func newOrder() *command.DomainModel {
+	dm := new(command.DomainModel)
+	dm.Init("Order", map[string]command.Transition{"order-placed": func(*event.Event) {}},
+		map[string]command.HandleFunc{"order/v1.placeOrder": func(c *command.Command) error {
+			dm.Causes(event.NewDomainEvent("order-placed", c.AggregateID()))
+			return nil
+		}})
+	return dm
+}
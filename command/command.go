@@ -1,11 +1,21 @@
 package command
 
 import (
+	"context"
 	"time"
 
+	"github.com/openyard/evently/event"
 	"github.com/openyard/evently/pkg/uuid"
 )
 
+// Metadata keys of a command
+const (
+	MetaUser          = "user"
+	MetaTenant        = "tenant"
+	MetaClient        = "client"
+	MetaCorrelationID = event.MetaCorrelationID
+)
+
 // Command defines the structure for an immutable command
 type Command struct {
 	name            string
@@ -14,13 +24,12 @@ type Command struct {
 	expectedVersion uint64
 	issuedAt        time.Time
 	payload         []byte
-
-	done chan bool
-	err  chan error
+	metadata        map[string]string
+	deadline        time.Time
 }
 
 // New returns a new command with given name and aggregateID and unique generated UUIDv4 issued now
-// without payload
+// without payload, unless set by the options
 func New(name, aggregateID string, opts ...Option) *Command {
 	c := &Command{
 		name:        name,
@@ -36,6 +45,14 @@ func New(name, aggregateID string, opts ...Option) *Command {
 
 type Option func(cmd *Command)
 
+// WithID sets the ID of the command instead of a generated one, e.g. the ID of a command
+// sent again, so it's recognized as a duplicate
+func WithID(id string) Option {
+	return func(c *Command) {
+		c.id = id
+	}
+}
+
 // WithPayload adds the given payload
 func WithPayload(payload []byte) Option {
 	return func(c *Command) {
@@ -43,6 +60,54 @@ func WithPayload(payload []byte) Option {
 	}
 }
 
+// WithDeadline sets the time the command must be executed by. A command not executed in
+// time fails with ErrTimeout.
+func WithDeadline(deadline time.Time) Option {
+	return func(c *Command) {
+		c.deadline = deadline
+	}
+}
+
+// WithTimeout sets the deadline of the command to the given duration after it's issued
+func WithTimeout(d time.Duration) Option {
+	return func(c *Command) {
+		c.deadline = c.issuedAt.Add(d)
+	}
+}
+
+// WithMetadata adds the given key-value pairs to the metadata of the command
+func WithMetadata(metadata map[string]string) Option {
+	return func(c *Command) {
+		if c.metadata == nil {
+			c.metadata = make(map[string]string, len(metadata))
+		}
+		for k, v := range metadata {
+			c.metadata[k] = v
+		}
+	}
+}
+
+// WithUser sets the user issuing the command
+func WithUser(user string) Option {
+	return WithMetadata(map[string]string{MetaUser: user})
+}
+
+// WithTenant sets the tenant the command is issued for
+func WithTenant(tenant string) Option {
+	return WithMetadata(map[string]string{MetaTenant: tenant})
+}
+
+// WithClient sets information about the client issuing the command, e.g. its user agent
+func WithClient(client string) Option {
+	return WithMetadata(map[string]string{MetaClient: client})
+}
+
+// WithCorrelationID sets the ID of the conversation the command belongs to, e.g. the
+// correlation ID of the event or request it reacts on
+func WithCorrelationID(correlationID string) Option {
+	return WithMetadata(map[string]string{MetaCorrelationID: correlationID})
+}
+
 // WithExpectedVersion sets the expected version
 func WithExpectedVersion(expectedVersion uint64) Option {
 	return func(c *Command) {
@@ -70,22 +135,74 @@ func (c *Command) Payload() []byte {
 	return c.payload
 }
 
-// Executed marks the command as done
-func (c *Command) Executed() {
-	c.done <- true
+// IssuedAt returns the time the command was created
+func (c *Command) IssuedAt() time.Time {
+	return c.issuedAt
+}
+
+// Deadline returns the time the command must be executed by, if any
+func (c *Command) Deadline() (time.Time, bool) {
+	return c.deadline, !c.deadline.IsZero()
+}
+
+// Context returns ctx with the deadline of the command, if any
+func (c *Command) Context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := c.Deadline(); ok {
+		return context.WithDeadline(ctx, deadline)
+	}
+	return context.WithCancel(ctx)
+}
+
+// withDeadline returns a copy of the command with the given deadline, or the command
+// itself if its deadline is earlier
+func (c *Command) withDeadline(deadline time.Time) *Command {
+	if current, ok := c.Deadline(); ok && current.Before(deadline) {
+		return c
+	}
+	limited := *c
+	limited.deadline = deadline
+	return &limited
+}
+
+// Metadata returns the metadata of the command
+func (c *Command) Metadata() map[string]string {
+	return c.metadata
 }
 
-// Failed marks the command as failed with given error
-func (c *Command) Failed(err error) {
-	c.err <- err
+// User returns the user issuing the command, if known
+func (c *Command) User() string {
+	return c.metadata[MetaUser]
 }
 
-// Error returns the error channel of the command
-func (c *Command) Error() <-chan error {
-	return c.err
+// Tenant returns the tenant the command is issued for, if known
+func (c *Command) Tenant() string {
+	return c.metadata[MetaTenant]
 }
 
-// Done returns the done channel of the command
-func (c *Command) Done() <-chan bool {
-	return c.done
+// Client returns information about the client issuing the command, if known
+func (c *Command) Client() string {
+	return c.metadata[MetaClient]
+}
+
+// CorrelationID returns the ID of the conversation the command belongs to. A command
+// without correlation ID starts a conversation, so it's its own ID.
+func (c *Command) CorrelationID() string {
+	if id, ok := c.metadata[MetaCorrelationID]; ok && id != "" {
+		return id
+	}
+	return c.id
+}
+
+// Propagate returns copies of the given events with the command as causation and its
+// correlation ID in their metadata
+func Propagate(c *Command, events ...*event.Event) []*event.Event {
+	metadata := map[string]string{
+		event.MetaCausationID:   c.id,
+		event.MetaCorrelationID: c.CorrelationID(),
+	}
+	propagated := make([]*event.Event, len(events))
+	for n, e := range events {
+		propagated[n] = e.Annotate(metadata)
+	}
+	return propagated
 }
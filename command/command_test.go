@@ -0,0 +1,54 @@
+package command_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openyard/evently/command"
+	"github.com/openyard/evently/event"
+	"github.com/openyard/evently/example"
+)
+
+func TestCommand_metadata(t *testing.T) {
+	before := time.Now()
+	c := command.New("onboard", "4711",
+		command.WithUser("jane"), command.WithTenant("acme"), command.WithClient("cli/1.0"),
+		command.WithMetadata(map[string]string{"ip": "127.0.0.1"}))
+	if c.User() != "jane" || c.Tenant() != "acme" || c.Client() != "cli/1.0" || c.Metadata()["ip"] != "127.0.0.1" {
+		t.Errorf("unexpected metadata %v", c.Metadata())
+	}
+	if c.IssuedAt().Before(before.Add(-time.Second)) || c.IssuedAt().After(time.Now()) {
+		t.Errorf("unexpected issuedAt %s", c.IssuedAt())
+	}
+	if c.CorrelationID() != c.CommandID() {
+		t.Error("expected command without correlation to start a conversation")
+	}
+	if correlated := command.New("activate", "4711", command.WithCorrelationID("c-1")); correlated.CorrelationID() != "c-1" {
+		t.Errorf("unexpected correlation ID %q", correlated.CorrelationID())
+	}
+}
+
+func TestDomainModel_Execute_propagation(t *testing.T) {
+	birthdate, _ := time.Parse("2006-01-02", "1999-06-01")
+	c := example.OnboardCustomer("4711", "John Doe", birthdate, 'M')
+	command.WithCorrelationID("request-1")(c)
+	changes, err := new(example.CustomerFactory).Create().Execute(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].CausationID() != c.CommandID() || changes[0].CorrelationID() != "request-1" {
+		t.Errorf("expected command as causation and its correlation, got %+v", changes)
+	}
+}
+
+func TestPropagate(t *testing.T) {
+	c := command.New("activate", "4711")
+	e := event.NewDomainEvent("activated", "4711", event.WithMetadata(map[string]string{event.MetaCorrelationID: "other"}))
+	propagated := command.Propagate(c, e)[0]
+	if propagated.CausationID() != c.CommandID() || propagated.CorrelationID() != "other" {
+		t.Errorf("expected causation added and correlation kept, got %v", propagated.Metadata())
+	}
+	if e.CausationID() != "" {
+		t.Error("expected given event unchanged")
+	}
+}
@@ -0,0 +1,253 @@
+package command
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/event"
+)
+
+const (
+	defaultDedupRetention      = 24 * time.Hour
+	defaultDedupExpiryInterval = time.Hour
+)
+
+// Outcome is the result of a processed command
+type Outcome struct {
+	CommandID   string
+	EventIDs    []string
+	Version     uint64
+	Err         error
+	ProcessedAt time.Time
+	// Duplicate reports whether the outcome is the one of an earlier processing
+	Duplicate bool
+}
+
+// NewOutcome returns the outcome of the command processed now
+func NewOutcome(c *Command, events []*event.Event, version uint64, err error) *Outcome {
+	eventIDs := make([]string, len(events))
+	for n, e := range events {
+		eventIDs[n] = e.ID()
+	}
+	return &Outcome{CommandID: c.CommandID(), EventIDs: eventIDs, Version: version, Err: err, ProcessedAt: time.Now()}
+}
+
+// OutcomeStore records the outcomes of processed commands
+type OutcomeStore interface {
+	// Outcome returns the outcome of the command or nil if it's unknown
+	Outcome(commandID string) (*Outcome, error)
+	StoreOutcome(outcome *Outcome) error
+	// Expire removes all outcomes processed before the given time and returns their number
+	Expire(before time.Time) (int, error)
+}
+
+type DedupOption func(d *Deduplicator)
+
+// Deduplicator processes every command ID once within the retention window. A repeated
+// command gets the outcome of its first processing, if it succeeded or the command was
+// rejected. Commands failed with a Retryable error are processed again. Use it with
+// either an async.CommandQueue or the Service handling its commands, not with both.
+type Deduplicator struct {
+	sync.Mutex
+	store          OutcomeStore
+	retryable      func(err error) bool
+	retention      time.Duration
+	expiryInterval time.Duration
+	expiredAt      time.Time
+	inflight       map[string]chan struct{}
+}
+
+// NewDeduplicator returns a Deduplicator recording outcomes in the given store
+func NewDeduplicator(store OutcomeStore, opts ...DedupOption) *Deduplicator {
+	d := &Deduplicator{
+		store:          store,
+		retryable:      Retryable,
+		retention:      defaultDedupRetention,
+		expiryInterval: defaultDedupExpiryInterval,
+		expiredAt:      time.Now(),
+		inflight:       make(map[string]chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// WithDedupRetention sets the time outcomes are kept, default is 24h
+func WithDedupRetention(retention time.Duration) DedupOption {
+	return func(d *Deduplicator) {
+		d.retention = retention
+	}
+}
+
+// WithDedupExpiryInterval sets how often expired outcomes are removed, default is 1h
+func WithDedupExpiryInterval(interval time.Duration) DedupOption {
+	return func(d *Deduplicator) {
+		d.expiryInterval = interval
+	}
+}
+
+// WithDedupRetryable sets the func deciding whether a failed command is processed again
+// instead of recording its outcome, default is Retryable
+func WithDedupRetryable(retryable func(err error) bool) DedupOption {
+	return func(d *Deduplicator) {
+		d.retryable = retryable
+	}
+}
+
+// Retryable reports whether a command failed with the given error may succeed when it's
+// processed again, i.e. it timed out, panicked or conflicted with a concurrent change.
+// Any other error is a rejection of the command.
+func Retryable(err error) bool {
+	var e *evently.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	switch e.Code {
+	case ErrTimeout, ErrPanic, es.ErrConcurrentChange:
+		return true
+	}
+	return false
+}
+
+// Process calls process unless the command was processed before, in that case it returns
+// the recorded outcome. A duplicate arriving while the command is processed waits for it.
+func (d *Deduplicator) Process(c *Command, process func(c *Command) *Outcome) *Outcome {
+	for {
+		outcome, wait, err := d.begin(c.CommandID())
+		if err != nil {
+			return &Outcome{CommandID: c.CommandID(), Err: err, ProcessedAt: time.Now()}
+		}
+		if outcome != nil {
+			duplicate := *outcome
+			duplicate.Duplicate = true
+			return &duplicate
+		}
+		if wait != nil {
+			<-wait
+			continue
+		}
+		break
+	}
+	var outcome *Outcome
+	defer func() {
+		if outcome == nil { // process panicked
+			d.release(c.CommandID())
+		}
+	}()
+	outcome = process(c)
+	d.end(outcome)
+	return outcome
+}
+
+// Middleware returns the Deduplicator as Middleware of handlers not reporting the IDs of
+// the produced events
+func (d *Deduplicator) Middleware() Middleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(c *Command) error {
+			return d.Process(c, func(c *Command) *Outcome {
+				return NewOutcome(c, nil, 0, next(c))
+			}).Err
+		}
+	}
+}
+
+// Expire removes all outcomes older than the retention window
+func (d *Deduplicator) Expire() (int, error) {
+	d.Lock()
+	defer d.Unlock()
+	return d.expire(time.Now())
+}
+
+// begin returns the outcome of the command, if known, or the channel to wait for if it's
+// in flight. Otherwise, the command is marked in flight.
+func (d *Deduplicator) begin(commandID string) (*Outcome, chan struct{}, error) {
+	d.Lock()
+	defer d.Unlock()
+	now := time.Now()
+	if now.Sub(d.expiredAt) >= d.expiryInterval {
+		if _, err := d.expire(now); err != nil {
+			return nil, nil, err
+		}
+	}
+	if wait, ok := d.inflight[commandID]; ok {
+		return nil, wait, nil
+	}
+	outcome, err := d.store.Outcome(commandID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if outcome != nil && now.Sub(outcome.ProcessedAt) < d.retention {
+		return outcome, nil, nil
+	}
+	d.inflight[commandID] = make(chan struct{})
+	return nil, nil, nil
+}
+
+// end records the outcome unless it's retryable and releases waiting duplicates. The
+// outcome stays as it is if it can't be recorded, the command is processed anyway.
+func (d *Deduplicator) end(outcome *Outcome) {
+	defer d.release(outcome.CommandID)
+	if outcome.Err != nil && d.retryable(outcome.Err) {
+		return
+	}
+	if err := d.store.StoreOutcome(outcome); err != nil {
+		logger.Error("couldn't store outcome", evently.LogCommandID, outcome.CommandID, evently.Err(err))
+	}
+}
+
+// release releases the duplicates waiting for the command in flight
+func (d *Deduplicator) release(commandID string) {
+	d.Lock()
+	defer d.Unlock()
+	close(d.inflight[commandID])
+	delete(d.inflight, commandID)
+}
+
+// expire removes expired outcomes. Must be called locked.
+func (d *Deduplicator) expire(now time.Time) (int, error) {
+	d.expiredAt = now
+	return d.store.Expire(now.Add(-d.retention))
+}
+
+var _ OutcomeStore = (*MemoryOutcomeStore)(nil)
+
+// MemoryOutcomeStore keeps outcomes in memory
+type MemoryOutcomeStore struct {
+	sync.RWMutex
+	outcomes map[string]*Outcome
+}
+
+func NewMemoryOutcomeStore() *MemoryOutcomeStore {
+	return &MemoryOutcomeStore{outcomes: make(map[string]*Outcome)}
+}
+
+func (s *MemoryOutcomeStore) Outcome(commandID string) (*Outcome, error) {
+	s.RLock()
+	defer s.RUnlock()
+	return s.outcomes[commandID], nil
+}
+
+func (s *MemoryOutcomeStore) StoreOutcome(outcome *Outcome) error {
+	s.Lock()
+	defer s.Unlock()
+	stored := *outcome
+	s.outcomes[outcome.CommandID] = &stored
+	return nil
+}
+
+func (s *MemoryOutcomeStore) Expire(before time.Time) (int, error) {
+	s.Lock()
+	defer s.Unlock()
+	var expired int
+	for id, outcome := range s.outcomes {
+		if outcome.ProcessedAt.Before(before) {
+			delete(s.outcomes, id)
+			expired++
+		}
+	}
+	return expired, nil
+}
@@ -0,0 +1,133 @@
+package command_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openyard/evently/command"
+	"github.com/openyard/evently/command/es/estest"
+	"github.com/openyard/evently/example"
+)
+
+func TestService_WithDeduplication(t *testing.T) {
+	store := estest.NewTestEventStore()
+	svc := command.NewService(store, new(example.CustomerFactory).Create,
+		command.WithDeduplication(command.NewDeduplicator(command.NewMemoryOutcomeStore())))
+	birthdate, _ := time.Parse("2006-01-02", "1999-06-01")
+	onboard := example.OnboardCustomer("4711", "John Doe", birthdate, 'M')
+	first := svc.ProcessOutcome(context.Background(), onboard)
+	if first.Err != nil || len(first.EventIDs) != 1 || first.Duplicate {
+		t.Fatalf("unexpected outcome %+v", first)
+	}
+	resent := svc.ProcessOutcome(context.Background(), onboard)
+	if resent.Err != nil || !resent.Duplicate || resent.EventIDs[0] != first.EventIDs[0] {
+		t.Errorf("expected original outcome, got %+v", resent)
+	}
+	if h, _ := store.ReadStream("4711"); len(h) != 1 {
+		t.Errorf("expected command executed once, got %d events", len(h))
+	}
+
+	again := example.OnboardCustomer("4711", "John Doe", birthdate, 'M')
+	failed := svc.ProcessOutcome(context.Background(), again)
+	if failed.Err == nil {
+		t.Fatal("expected onboarding an existing customer to fail")
+	}
+	if err := svc.Process(again); err == nil || err.Error() != failed.Err.Error() {
+		t.Errorf("expected original error, got %v", err)
+	}
+}
+
+func TestService_WithDeduplication_resent(t *testing.T) {
+	store := estest.NewTestEventStore()
+	svc := command.NewService(store, new(example.CustomerFactory).Create,
+		command.WithDeduplication(command.NewDeduplicator(command.NewMemoryOutcomeStore())))
+	activate := example.ActivateCustomer("4711")
+	first := svc.ProcessOutcome(context.Background(), activate)
+	if first.Err != nil || first.Duplicate {
+		t.Fatalf("unexpected outcome %+v", first)
+	}
+	// the client sends the command again, e.g. after a restart
+	resent := command.New(activate.CommandName(), "4711", command.WithID(activate.CommandID()))
+	outcome := svc.ProcessOutcome(context.Background(), resent)
+	if !outcome.Duplicate || outcome.Version != first.Version || outcome.EventIDs[0] != first.EventIDs[0] {
+		t.Errorf("expected original outcome, got %+v", outcome)
+	}
+	if h, _ := store.ReadStream("4711"); len(h) != 1 {
+		t.Errorf("expected command executed once, got %d events", len(h))
+	}
+}
+
+func TestDeduplicator(t *testing.T) {
+	d := command.NewDeduplicator(command.NewMemoryOutcomeStore(), command.WithDedupRetention(50*time.Millisecond))
+	var calls atomic.Int32
+	h := d.Middleware()(func(*command.Command) error {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return errors.New("failed")
+	})
+	c := command.New("activate", "4711")
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := h(c); err == nil {
+				t.Error("expected error of first handling")
+			}
+		}()
+	}
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Errorf("expected concurrent duplicates handled once, got %d", calls.Load())
+	}
+	time.Sleep(60 * time.Millisecond)
+	if n, err := d.Expire(); err != nil || n != 1 {
+		t.Fatalf("expected 1 outcome expired, got %d (%v)", n, err)
+	}
+	_ = h(c)
+	if calls.Load() != 2 {
+		t.Error("expected command handled again after retention")
+	}
+}
+
+func TestDeduplicator_retryable(t *testing.T) {
+	d := command.NewDeduplicator(command.NewMemoryOutcomeStore())
+	var calls int
+	h := d.Middleware()(func(c *command.Command) error {
+		calls++
+		if calls == 1 {
+			return command.TimedOut(c, context.DeadlineExceeded)
+		}
+		return nil
+	})
+	c := command.New("activate", "4711")
+	if err := h(c); err == nil {
+		t.Fatal("expected timeout of first handling")
+	}
+	if err := h(c); err != nil || calls != 2 {
+		t.Errorf("expected timed out command handled again, got %v after %d calls", err, calls)
+	}
+	if err := h(c); err != nil || calls != 2 {
+		t.Errorf("expected outcome of successful handling, got %v after %d calls", err, calls)
+	}
+}
+
+func TestDeduplicator_storeFailure(t *testing.T) {
+	d := command.NewDeduplicator(failingOutcomeStore{command.NewMemoryOutcomeStore()})
+	h := d.Middleware()(func(*command.Command) error { return nil })
+	if err := h(command.New("activate", "4711")); err != nil {
+		t.Errorf("expected success although its outcome isn't stored, got %v", err)
+	}
+}
+
+type failingOutcomeStore struct {
+	*command.MemoryOutcomeStore
+}
+
+func (failingOutcomeStore) StoreOutcome(*command.Outcome) error {
+	return errors.New("store unavailable")
+}
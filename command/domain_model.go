@@ -1,7 +1,9 @@
 package command
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -11,6 +13,8 @@ import (
 	"github.com/openyard/evently/pkg/timeutil"
 )
 
+var logger = evently.Logger("command")
+
 // Transition is a func to apply the given ddd.DomainEvent
 type Transition func(e *event.Event)
 
@@ -19,8 +23,11 @@ type DomainModel struct {
 	id, name string
 	version  uint64
 	history  *timeutil.TemporalCollection
+	applied  []recorded
 
 	changes         []*event.Event
+	executing       *Command
+	middleware      Middleware
 	commandHandlers map[string]HandleFunc
 	transitions     map[string]Transition
 }
@@ -35,6 +42,23 @@ func (dm *DomainModel) Init(name string, transitions map[string]Transition, comm
 	dm.commandHandlers = commandHandlers
 }
 
+// Use wraps all command handlers of the model with the given middlewares
+func (dm *DomainModel) Use(middlewares ...Middleware) {
+	dm.Lock()
+	defer dm.Unlock()
+	dm.middleware = Chain(middlewares...)
+}
+
+// Commands returns the names of all commands the model handles
+func (dm *DomainModel) Commands() []string {
+	commands := make([]string, 0, len(dm.commandHandlers))
+	for c := range dm.commandHandlers {
+		commands = append(commands, c)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
 func (dm *DomainModel) EntityID() string {
 	return dm.id
 }
@@ -43,16 +67,49 @@ func (dm *DomainModel) AggregateName() string {
 	return dm.name
 }
 
+// History returns the applied events by valid time and transaction time
 func (dm *DomainModel) History() *timeutil.TemporalCollection {
 	return dm.history
 }
 
+// StateAt returns the model if it has a state valid at the given time.
+//
+// Deprecated: the returned model is in its latest state, use StateAsOf to rebuild the
+// state valid at the given time.
 func (dm *DomainModel) StateAt(at time.Time) (*DomainModel, error) {
-	model, err := dm.history.Get(at)
-	return model.(*DomainModel), err
+	if _, err := dm.history.Get(at); err != nil {
+		return nil, err
+	}
+	return dm, nil
 }
 
+// StateAsOf returns the state of the model valid at the given time as known at the
+// given transaction time. Changes recorded after knownAt, e.g. retroactive corrections,
+// aren't taken into account. The state is rebuilt in a new model of the given CreateFunc
+// by replaying the events occurred before at and recorded up to knownAt, like
+// es.BitemporalReader reads them.
+func (dm *DomainModel) StateAsOf(create CreateFunc, at, knownAt time.Time) (*DomainModel, error) {
+	var events es.History
+	for _, r := range dm.applied {
+		if r.event.OccurredAt().Before(at) && !r.recordedAt.After(knownAt) {
+			events = append(events, r.event)
+		}
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("no events occurred before %s as known at %s", at, knownAt)
+	}
+	model := create()
+	model.Load(events)
+	return model, nil
+}
+
+// Causes applies the given events as changes. Events caused while executing a command
+// record the command as causation and inherit its correlation ID. Integration events
+// are changes as well, but change neither the state nor the version of the model.
 func (dm *DomainModel) Causes(events ...*event.Event) {
+	if dm.executing != nil {
+		events = Propagate(dm.executing, events...)
+	}
 	dm.changes = append(dm.changes, events...)
 	dm.apply(events...)
 }
@@ -65,12 +122,29 @@ func (dm *DomainModel) Load(history es.History) {
 	dm.apply(history...)
 }
 
+// Execute handles the command and returns the caused changes. A command handled after
+// its deadline fails with ErrTimeout.
 func (dm *DomainModel) Execute(c *Command) ([]*event.Event, error) {
 	ch, ok := dm.commandHandlers[c.CommandName()]
 	if !ok {
 		return nil, evently.Errorf(ErrUnknownCommand, "ErrUnknownCommand", "[%T] unknown command: %q", dm, c.CommandName())
 	}
-	err := ch.Handle(c)
+	dm.Lock()
+	middleware := dm.middleware
+	dm.Unlock()
+	handle := HandleFunc(func(c *Command) error {
+		dm.executing = c // as passed on by the middlewares
+		err := ch.Handle(c)
+		if deadline, ok := c.Deadline(); ok && err == nil && time.Now().After(deadline) {
+			return TimedOut(c, context.DeadlineExceeded)
+		}
+		return err
+	})
+	if middleware != nil {
+		handle = middleware(handle)
+	}
+	defer func() { dm.executing = nil }()
+	err := handle(c)
 	return dm.changes, err
 }
 
@@ -78,13 +152,28 @@ func (dm *DomainModel) Version() uint64 {
 	return dm.version
 }
 
+// recorded is an applied event with the time it became known to the model
+type recorded struct {
+	event      *event.Event
+	recordedAt time.Time
+}
+
 func (dm *DomainModel) apply(events ...*event.Event) {
-	defer dm.history.Put(events[len(events)-1].OccurredAt(), dm) // save state at point in time
+	now := time.Now()
 	for _, e := range events {
+		if e.Kind() == event.IntegrationEvent { // published through the outbox, doesn't change the state
+			continue
+		}
+		recordedAt := e.RecordedAt()
+		if recordedAt.IsZero() {
+			recordedAt = now // not recorded yet, known since it's applied
+		}
+		dm.history.PutRecorded(e.OccurredAt(), recordedAt, e)
+		dm.applied = append(dm.applied, recorded{event: e, recordedAt: recordedAt})
 		dm.version++
 		eh, known := dm.transitions[e.Name()]
 		if !known {
-			log.Printf("[%T] unhandled event %q", dm, e.Name())
+			logger.Warn("unhandled event", "aggregate", dm.name, evently.LogAggregateID, e.AggregateID(), "event", e.Name())
 			continue
 		}
 		eh(e)
@@ -0,0 +1,98 @@
+package command_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openyard/evently/command"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/command/es/estest"
+	"github.com/openyard/evently/event"
+	"github.com/openyard/evently/example"
+)
+
+const (
+	onboarded = "Customer/v1.newCustomerOnboarded"
+	blocked   = "Customer/v1.customerBlocked"
+)
+
+func TestDomainModel_StateAsOf(t *testing.T) {
+	create := new(example.CustomerFactory).Create
+	lastMonth := time.Now().AddDate(0, -1, 0)
+	knownBefore := time.Now()
+	correctedAt := knownBefore.Add(time.Millisecond)
+	dm := create()
+	dm.Load(es.History{event.NewEventAt(onboarded, "4711", lastMonth).Record(lastMonth)})
+	// retroactive correction, blocked two weeks ago but recorded after knownBefore
+	dm.Load(es.History{event.NewEventAt(blocked, "4711", lastMonth.AddDate(0, 0, 14)).Record(correctedAt)})
+
+	tests := []struct {
+		at, knownAt time.Time
+		version     uint64
+	}{
+		{time.Now(), knownBefore, 1},
+		{time.Now(), correctedAt, 2},
+		{lastMonth.Add(time.Hour), correctedAt, 1},
+	}
+	for _, test := range tests {
+		state, err := dm.StateAsOf(create, test.at, test.knownAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Version() != test.version {
+			t.Errorf("unexpected version at %s as of %s: %d expected %d", test.at, test.knownAt, state.Version(), test.version)
+		}
+	}
+	if dm.Version() != 2 {
+		t.Errorf("model changed by reading its state: version %d expected 2", dm.Version())
+	}
+	if _, err := dm.StateAsOf(create, lastMonth.Add(-time.Hour), time.Now()); err == nil {
+		t.Error("expected no state before the first event")
+	}
+}
+
+func TestDomainModel_StateAsOf_boundary(t *testing.T) {
+	create := new(example.CustomerFactory).Create
+	store := estest.NewTestEventStore()
+	occurredAt := time.Now().AddDate(0, -1, 0)
+	_ = store.AppendToStream("4711", 0, event.NewEventAt(onboarded, "4711", occurredAt))
+	h, _ := store.ReadStream("4711")
+	dm := create()
+	dm.Load(h)
+
+	// both exclude an event occurred exactly at the given time
+	if known, _ := store.ReadStreamAsOf("4711", occurredAt, time.Now()); len(known) != 0 {
+		t.Errorf("expected no events read before %s, got %d", occurredAt, len(known))
+	}
+	if _, err := dm.StateAsOf(create, occurredAt, time.Now()); err == nil {
+		t.Errorf("expected no state before %s", occurredAt)
+	}
+	after := occurredAt.Add(time.Nanosecond)
+	if known, _ := store.ReadStreamAsOf("4711", after, time.Now()); len(known) != 1 {
+		t.Errorf("expected the event read before %s, got %d", after, len(known))
+	}
+	if state, err := dm.StateAsOf(create, after, time.Now()); err != nil || state.Version() != 1 {
+		t.Errorf("expected the state of the event before %s, got %v", after, err)
+	}
+}
+
+func TestService_LoadAsOf(t *testing.T) {
+	store := estest.NewTestEventStore()
+	svc := command.NewService(store, new(example.CustomerFactory).Create)
+	lastMonth := time.Now().AddDate(0, -1, 0)
+	_ = store.AppendToStream("4711", 0, event.NewEventAt(onboarded, "4711", lastMonth))
+	knownBefore := time.Now()
+	time.Sleep(time.Millisecond)
+	_ = store.AppendToStream("4711", 1, event.NewEventAt(blocked, "4711", lastMonth.AddDate(0, 0, 14)))
+
+	dm, err := svc.LoadAsOf("4711", time.Now(), knownBefore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dm.Version() != 1 {
+		t.Errorf("unexpected version as known before the correction: %d expected 1", dm.Version())
+	}
+	if dm, _ = svc.LoadAsOf("4711", time.Now(), time.Now()); dm.Version() != 2 {
+		t.Errorf("unexpected version as known now: %d expected 2", dm.Version())
+	}
+}
@@ -6,4 +6,10 @@ const (
 	ErrTimeout = iota + 9001
 	// ErrUnknownCommand thrown when given Command is unknown
 	ErrUnknownCommand
+	// ErrPanic thrown when a command handler panics
+	ErrPanic
+	// ErrInvalidCommand thrown when given Command fails validation
+	ErrInvalidCommand
+	// ErrUnauthorized thrown when the issuer of given Command isn't authorized
+	ErrUnauthorized
 )
@@ -0,0 +1,228 @@
+package es
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	defaultLiveBuffer = 16
+	defaultPageSize   = 1000
+)
+
+type BroadcastOption func(b *Broadcaster)
+
+// Broadcaster fans new entries of a log out to all its subscribers. Every subscriber
+// has its own channel and offset. Publishing never blocks: a subscriber which can't
+// keep up misses live entries and reads them from the log instead (catch-up read).
+type Broadcaster struct {
+	sync.Mutex
+	log         LogReader
+	subscribers map[*subscriber]struct{}
+	liveBuffer  int
+	pageSize    int
+}
+
+// NewBroadcaster returns a Broadcaster whose subscribers catch up by reading the given log
+func NewBroadcaster(log LogReader, opts ...BroadcastOption) *Broadcaster {
+	b := &Broadcaster{
+		log:         log,
+		subscribers: make(map[*subscriber]struct{}),
+		liveBuffer:  defaultLiveBuffer,
+		pageSize:    defaultPageSize,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// WithLiveBuffer sets the number of published batches buffered per subscriber, default is 16
+func WithLiveBuffer(n int) BroadcastOption {
+	return func(b *Broadcaster) {
+		b.liveBuffer = n
+	}
+}
+
+// WithPageSize sets the maximum number of entries per catch-up read, default is 1000
+func WithPageSize(n int) BroadcastOption {
+	return func(b *Broadcaster) {
+		b.pageSize = n
+	}
+}
+
+// Subscribe returns a new Subscription receiving all entries of the log starting at the
+// given offset. A failing catch-up read ends the subscription with the read error.
+func (b *Broadcaster) Subscribe(offset uint64) Subscription {
+	b.Lock()
+	defer b.Unlock()
+	s := &subscriber{
+		broadcaster: b,
+		offset:      offset,
+		out:         make(chan []*Entry),
+		live:        make(chan []*Entry, b.liveBuffer),
+		wake:        make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		caughtUp:    make(chan struct{}),
+	}
+	b.subscribers[s] = struct{}{}
+	go s.run()
+	return s
+}
+
+// Publish passes the appended entries to all subscribers without blocking. It must be
+// called in order of the global position once the entries are readable from the log.
+func (b *Broadcaster) Publish(entries ...*Entry) {
+	if len(entries) == 0 {
+		return
+	}
+	b.Lock()
+	defer b.Unlock()
+	for s := range b.subscribers {
+		select {
+		case s.live <- entries:
+		default: // subscriber is behind, let it catch up
+			select {
+			case s.wake <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// Close ends all subscriptions
+func (b *Broadcaster) Close() {
+	b.Lock()
+	subscribers := make([]*subscriber, 0, len(b.subscribers))
+	for s := range b.subscribers {
+		subscribers = append(subscribers, s)
+	}
+	b.Unlock()
+	for _, s := range subscribers {
+		s.Close()
+	}
+}
+
+func (b *Broadcaster) remove(s *subscriber) {
+	b.Lock()
+	defer b.Unlock()
+	delete(b.subscribers, s)
+}
+
+type subscriber struct {
+	broadcaster *Broadcaster
+	offset      uint64
+	out         chan []*Entry
+	live        chan []*Entry
+	wake        chan struct{}
+	done        chan struct{}
+	closeOnce   sync.Once
+
+	phase      atomic.Int32
+	caughtUp   chan struct{}
+	caughtOnce sync.Once
+	err        atomic.Value
+}
+
+func (s *subscriber) Entries() <-chan []*Entry {
+	return s.out
+}
+
+func (s *subscriber) Err() error {
+	if err, ok := s.err.Load().(error); ok {
+		return err
+	}
+	return nil
+}
+
+func (s *subscriber) Phase() Phase {
+	return Phase(s.phase.Load())
+}
+
+func (s *subscriber) Live() <-chan struct{} {
+	return s.caughtUp
+}
+
+func (s *subscriber) Close() {
+	s.closeOnce.Do(func() {
+		s.broadcaster.remove(s)
+		close(s.done)
+	})
+}
+
+func (s *subscriber) run() {
+	defer close(s.out)
+	defer s.Close()
+	if !s.catchUp() {
+		return
+	}
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.wake:
+			if !s.catchUp() {
+				return
+			}
+		case batch := <-s.live:
+			entries := s.unseen(batch)
+			if len(entries) == 0 {
+				continue
+			}
+			if entries[0].GlobalPos != s.offset { // missed entries in between
+				if !s.catchUp() {
+					return
+				}
+				continue
+			}
+			if !s.send(entries) {
+				return
+			}
+		}
+	}
+}
+
+// catchUp reads the log until the end and reports false if the subscription ended
+func (s *subscriber) catchUp() bool {
+	s.phase.Store(int32(CatchUp))
+	for {
+		entries, err := s.broadcaster.log.ReadLog(s.offset, s.broadcaster.pageSize)
+		if err != nil {
+			s.err.Store(err)
+			return false
+		}
+		if len(entries) == 0 {
+			s.phase.Store(int32(Live))
+			s.caughtOnce.Do(func() { close(s.caughtUp) })
+			return true
+		}
+		if !s.send(entries) {
+			return false
+		}
+	}
+}
+
+func (s *subscriber) send(entries []*Entry) bool {
+	select {
+	case <-s.done: // stopped, even if the receiver is ready as well
+		return false
+	default:
+	}
+	select {
+	case s.out <- entries:
+		s.offset = entries[len(entries)-1].GlobalPos + 1
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// unseen returns the entries starting at the offset of the subscriber
+func (s *subscriber) unseen(batch []*Entry) []*Entry {
+	for i, entry := range batch {
+		if entry.GlobalPos >= s.offset {
+			return batch[i:]
+		}
+	}
+	return nil
+}
@@ -5,4 +5,5 @@ const (
 	ErrReadStreamFailed = iota + 9101
 	ErrConcurrentChange
 	ErrListen
+	ErrUnsupported
 )
@@ -0,0 +1,145 @@
+package esgrpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/command/es/esgrpc/pb"
+	"github.com/openyard/evently/event"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ es.EventStore = (*Client)(nil)
+var _ es.Transport = (*Client)(nil)
+
+// Client is an es.EventStore and es.Transport backed by a Server. Failed calls return
+// the evently.Error of the server, e.g. es.ErrConcurrentChange on conflicts.
+type Client struct {
+	client pb.EventStoreClient
+}
+
+// NewClient returns a Client calling the Server over the given connection
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{client: pb.NewEventStoreClient(conn)}
+}
+
+func (c *Client) ReadStream(stream string) (es.History, error) {
+	res, err := c.client.ReadStream(context.Background(), &pb.ReadStreamRequest{Stream: stream})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromEvents(res.GetEvents()), nil
+}
+
+func (c *Client) ReadStreamAt(stream string, at time.Time) (es.History, error) {
+	res, err := c.client.ReadStreamAt(context.Background(), &pb.ReadStreamAtRequest{Stream: stream, At: timestamppb.New(at)})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromEvents(res.GetEvents()), nil
+}
+
+func (c *Client) AppendToStream(stream string, expectedVersion uint64, events ...*event.Event) error {
+	req := &pb.AppendToStreamRequest{Stream: stream, ExpectedVersion: expectedVersion, Events: toEvents(events)}
+	if _, err := c.client.AppendToStream(context.Background(), req); err != nil {
+		return fromStatus(err)
+	}
+	return nil
+}
+
+// Subscribe listens for new entries starting at the head of the global log
+func (c *Client) Subscribe() es.Subscription {
+	return c.subscribe(&pb.SubscribeRequest{})
+}
+
+// SubscribeWithOffset fetches remaining entries based on given offset and listens for new entries
+func (c *Client) SubscribeWithOffset(offset uint64) es.Subscription {
+	return c.subscribe(&pb.SubscribeRequest{Offset: &offset})
+}
+
+func (c *Client) subscribe(req *pb.SubscribeRequest) *subscription {
+	s := &subscription{out: make(chan []*es.Entry), caughtUp: make(chan struct{})}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	go s.run(c.client, req)
+	return s
+}
+
+// subscription receives the entries of a server-streaming Subscribe call
+type subscription struct {
+	out       chan []*es.Entry
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+
+	phase      atomic.Int32
+	caughtUp   chan struct{}
+	caughtOnce sync.Once
+	err        atomic.Value
+}
+
+func (s *subscription) Entries() <-chan []*es.Entry {
+	return s.out
+}
+
+func (s *subscription) Err() error {
+	if err, ok := s.err.Load().(error); ok {
+		return err
+	}
+	return nil
+}
+
+func (s *subscription) Phase() es.Phase {
+	return es.Phase(s.phase.Load())
+}
+
+func (s *subscription) Live() <-chan struct{} {
+	return s.caughtUp
+}
+
+func (s *subscription) Close() {
+	s.closeOnce.Do(s.cancel)
+}
+
+func (s *subscription) run(client pb.EventStoreClient, req *pb.SubscribeRequest) {
+	defer close(s.out)
+	defer s.Close()
+	stream, err := client.Subscribe(s.ctx, req)
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	for {
+		res, err := stream.Recv()
+		if err != nil {
+			s.fail(err)
+			return
+		}
+		if len(res.GetEntries()) > 0 {
+			if s.ctx.Err() != nil {
+				return
+			}
+			select {
+			case s.out <- fromEntries(res.GetEntries()):
+			case <-s.ctx.Done():
+				return
+			}
+		}
+		if res.GetPhase() == pb.Phase_PHASE_LIVE {
+			s.phase.Store(int32(es.Live))
+			s.caughtOnce.Do(func() { close(s.caughtUp) })
+		} else {
+			s.phase.Store(int32(es.CatchUp))
+		}
+	}
+}
+
+// fail ends the subscription with the error of the call unless it was closed
+func (s *subscription) fail(err error) {
+	if s.ctx.Err() == nil {
+		s.err.Store(fromStatus(err))
+	}
+}
@@ -0,0 +1,72 @@
+package esgrpc
+
+import (
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/command/es/esgrpc/pb"
+	"github.com/openyard/evently/event"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toEvent(e *event.Event) *pb.Event {
+	msg := &pb.Event{
+		Id:          e.ID(),
+		Kind:        string(e.Kind()),
+		Name:        e.Name(),
+		AggregateId: e.AggregateID(),
+		Payload:     e.Payload(),
+		Metadata:    e.Metadata(),
+		OccurredAt:  timestamppb.New(e.OccurredAt()),
+	}
+	if !e.RecordedAt().IsZero() {
+		msg.RecordedAt = timestamppb.New(e.RecordedAt())
+	}
+	return msg
+}
+
+func fromEvent(msg *pb.Event) *event.Event {
+	opts := []event.Option{
+		event.WithID(msg.GetId()),
+		event.WithEventType(event.Type(msg.GetKind())),
+		event.WithPayload(msg.GetPayload()),
+	}
+	if len(msg.GetMetadata()) > 0 {
+		opts = append(opts, event.WithMetadata(msg.GetMetadata()))
+	}
+	e := event.NewEventAt(msg.GetName(), msg.GetAggregateId(), msg.GetOccurredAt().AsTime(), opts...)
+	if msg.GetRecordedAt() != nil {
+		e = e.Record(msg.GetRecordedAt().AsTime())
+	}
+	return e
+}
+
+func toEvents(history []*event.Event) []*pb.Event {
+	msgs := make([]*pb.Event, len(history))
+	for i, e := range history {
+		msgs[i] = toEvent(e)
+	}
+	return msgs
+}
+
+func fromEvents(msgs []*pb.Event) es.History {
+	history := make(es.History, len(msgs))
+	for i, msg := range msgs {
+		history[i] = fromEvent(msg)
+	}
+	return history
+}
+
+func toEntries(entries []*es.Entry) []*pb.Entry {
+	msgs := make([]*pb.Entry, len(entries))
+	for i, entry := range entries {
+		msgs[i] = &pb.Entry{GlobalPos: entry.GlobalPos, Stream: entry.Stream, Version: entry.Version, Event: toEvent(entry.Event)}
+	}
+	return msgs
+}
+
+func fromEntries(msgs []*pb.Entry) []*es.Entry {
+	entries := make([]*es.Entry, len(msgs))
+	for i, msg := range msgs {
+		entries[i] = &es.Entry{GlobalPos: msg.GetGlobalPos(), Stream: msg.GetStream(), Version: msg.GetVersion(), Event: fromEvent(msg.GetEvent())}
+	}
+	return entries
+}
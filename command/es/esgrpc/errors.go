@@ -0,0 +1,8 @@
+package esgrpc
+
+// error codes
+const (
+	ErrBadRequest = iota + 9601
+	ErrRequest
+	ErrRemote
+)
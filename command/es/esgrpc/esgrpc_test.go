@@ -0,0 +1,125 @@
+package esgrpc_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/command/es/esgrpc"
+	"github.com/openyard/evently/command/es/esgrpc/pb"
+	"github.com/openyard/evently/command/es/estest"
+	"github.com/openyard/evently/event"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestClient_Conformance(t *testing.T) {
+	estest.TestConformance(t, func() es.EventStore {
+		return serve(t, estest.NewTestEventStore())
+	})
+}
+
+func TestClient_errors(t *testing.T) {
+	client := serve(t, streamsOnly{estest.NewTestEventStore()})
+
+	_ = client.AppendToStream("customer-1", 0, event.NewDomainEvent("created", "1"))
+	err := client.AppendToStream("customer-1", 0, event.NewDomainEvent("created", "1"))
+	assertCode(t, err, es.ErrConcurrentChange)
+	err = client.AppendToStream("", 0, event.NewDomainEvent("created", "1"))
+	assertCode(t, err, esgrpc.ErrBadRequest)
+
+	s := client.SubscribeWithOffset(0)
+	defer s.Close()
+	select {
+	case _, ok := <-s.Entries():
+		if ok {
+			t.Fatal("expected the subscription to end")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription didn't end")
+	}
+	assertCode(t, s.Err(), es.ErrUnsupported)
+}
+
+func TestClient_events(t *testing.T) {
+	client := serve(t, estest.NewTestEventStore())
+	occurredAt := time.Date(2023, 1, 1, 12, 0, 0, 42, time.UTC)
+	e := event.NewEventAt("created", "1", occurredAt,
+		event.WithEventType(event.IntegrationEvent),
+		event.WithPayload([]byte(`{"name":"Jane"}`)),
+		event.WithMetadata(map[string]string{"correlation": "c-1"}))
+	if err := client.AppendToStream("customer-1", 0, e); err != nil {
+		t.Fatalf("append failed: %s", err)
+	}
+
+	history, err := client.ReadStream("customer-1")
+	if err != nil || len(history) != 1 {
+		t.Fatalf("read stream failed: %v %v", history, err)
+	}
+	got := history[0]
+	if got.ID() != e.ID() || got.Kind() != event.IntegrationEvent || string(got.Payload()) != `{"name":"Jane"}` ||
+		got.Metadata()["correlation"] != "c-1" || !got.OccurredAt().Equal(occurredAt) || got.RecordedAt().IsZero() {
+		t.Errorf("unexpected event %+v", got)
+	}
+}
+
+func TestServer_AppendToStream_malformed(t *testing.T) {
+	client := pb.NewEventStoreClient(dial(t, estest.NewTestEventStore()))
+	valid := &pb.Event{Id: "e-1", Name: "created", AggregateId: "1", OccurredAt: timestamppb.Now()}
+	for _, malformed := range []*pb.Event{
+		{Name: "created", AggregateId: "1", OccurredAt: timestamppb.Now()},
+		{Id: "e-1", AggregateId: "1", OccurredAt: timestamppb.Now()},
+		{Id: "e-1", Name: "created", OccurredAt: timestamppb.Now()},
+		{Id: "e-1", Name: "created", AggregateId: "1"},
+	} {
+		_, err := client.AppendToStream(context.Background(), &pb.AppendToStreamRequest{Stream: "customer-1", Events: []*pb.Event{valid, malformed}})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected %v rejected as invalid argument, got %v", malformed, err)
+		}
+	}
+}
+
+// serve starts a gRPC server for the store on a loopback listener and returns its client
+func serve(t *testing.T, store es.EventStore) *esgrpc.Client {
+	t.Helper()
+	return esgrpc.NewClient(dial(t, store))
+}
+
+// dial starts a gRPC server for the store on a loopback listener and returns a connection to it
+func dial(t *testing.T, store es.EventStore) *grpc.ClientConn {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	esgrpc.NewServer(store).Register(server)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func assertCode(t *testing.T, err error, code uint) {
+	t.Helper()
+	var e *evently.Error
+	if !errors.As(err, &e) || e.Code != code {
+		t.Errorf("expected error code %d, got %v", code, err)
+	}
+}
+
+// streamsOnly hides all but the es.EventStore methods of the store
+type streamsOnly struct {
+	es.EventStore
+}
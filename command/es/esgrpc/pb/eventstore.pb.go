@@ -0,0 +1,784 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: eventstore.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Phase int32
+
+const (
+	Phase_PHASE_CATCH_UP Phase = 0
+	Phase_PHASE_LIVE     Phase = 1
+)
+
+// Enum value maps for Phase.
+var (
+	Phase_name = map[int32]string{
+		0: "PHASE_CATCH_UP",
+		1: "PHASE_LIVE",
+	}
+	Phase_value = map[string]int32{
+		"PHASE_CATCH_UP": 0,
+		"PHASE_LIVE":     1,
+	}
+)
+
+func (x Phase) Enum() *Phase {
+	p := new(Phase)
+	*p = x
+	return p
+}
+
+func (x Phase) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Phase) Descriptor() protoreflect.EnumDescriptor {
+	return file_eventstore_proto_enumTypes[0].Descriptor()
+}
+
+func (Phase) Type() protoreflect.EnumType {
+	return &file_eventstore_proto_enumTypes[0]
+}
+
+func (x Phase) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Phase.Descriptor instead.
+func (Phase) EnumDescriptor() ([]byte, []int) {
+	return file_eventstore_proto_rawDescGZIP(), []int{0}
+}
+
+type Event struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Kind        string                 `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	Name        string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	AggregateId string                 `protobuf:"bytes,4,opt,name=aggregate_id,json=aggregateId,proto3" json:"aggregate_id,omitempty"`
+	Payload     []byte                 `protobuf:"bytes,5,opt,name=payload,proto3" json:"payload,omitempty"`
+	Metadata    map[string]string      `protobuf:"bytes,6,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// valid time, the time the event happened in the domain
+	OccurredAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	// transaction time, the time the event was recorded in the event store
+	RecordedAt    *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=recorded_at,json=recordedAt,proto3" json:"recorded_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_eventstore_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_eventstore_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_eventstore_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Event) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Event) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Event) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Event) GetAggregateId() string {
+	if x != nil {
+		return x.AggregateId
+	}
+	return ""
+}
+
+func (x *Event) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Event) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Event) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+func (x *Event) GetRecordedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RecordedAt
+	}
+	return nil
+}
+
+type Entry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GlobalPos     uint64                 `protobuf:"varint,1,opt,name=global_pos,json=globalPos,proto3" json:"global_pos,omitempty"`
+	Stream        string                 `protobuf:"bytes,2,opt,name=stream,proto3" json:"stream,omitempty"`
+	Version       uint64                 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	Event         *Event                 `protobuf:"bytes,4,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Entry) Reset() {
+	*x = Entry{}
+	mi := &file_eventstore_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Entry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Entry) ProtoMessage() {}
+
+func (x *Entry) ProtoReflect() protoreflect.Message {
+	mi := &file_eventstore_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Entry.ProtoReflect.Descriptor instead.
+func (*Entry) Descriptor() ([]byte, []int) {
+	return file_eventstore_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Entry) GetGlobalPos() uint64 {
+	if x != nil {
+		return x.GlobalPos
+	}
+	return 0
+}
+
+func (x *Entry) GetStream() string {
+	if x != nil {
+		return x.Stream
+	}
+	return ""
+}
+
+func (x *Entry) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Entry) GetEvent() *Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+type AppendToStreamRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Stream          string                 `protobuf:"bytes,1,opt,name=stream,proto3" json:"stream,omitempty"`
+	ExpectedVersion uint64                 `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	Events          []*Event               `protobuf:"bytes,3,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *AppendToStreamRequest) Reset() {
+	*x = AppendToStreamRequest{}
+	mi := &file_eventstore_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendToStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendToStreamRequest) ProtoMessage() {}
+
+func (x *AppendToStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_eventstore_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendToStreamRequest.ProtoReflect.Descriptor instead.
+func (*AppendToStreamRequest) Descriptor() ([]byte, []int) {
+	return file_eventstore_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AppendToStreamRequest) GetStream() string {
+	if x != nil {
+		return x.Stream
+	}
+	return ""
+}
+
+func (x *AppendToStreamRequest) GetExpectedVersion() uint64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+func (x *AppendToStreamRequest) GetEvents() []*Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type AppendToStreamResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendToStreamResponse) Reset() {
+	*x = AppendToStreamResponse{}
+	mi := &file_eventstore_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendToStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendToStreamResponse) ProtoMessage() {}
+
+func (x *AppendToStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_eventstore_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendToStreamResponse.ProtoReflect.Descriptor instead.
+func (*AppendToStreamResponse) Descriptor() ([]byte, []int) {
+	return file_eventstore_proto_rawDescGZIP(), []int{3}
+}
+
+type ReadStreamRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stream        string                 `protobuf:"bytes,1,opt,name=stream,proto3" json:"stream,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadStreamRequest) Reset() {
+	*x = ReadStreamRequest{}
+	mi := &file_eventstore_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadStreamRequest) ProtoMessage() {}
+
+func (x *ReadStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_eventstore_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadStreamRequest.ProtoReflect.Descriptor instead.
+func (*ReadStreamRequest) Descriptor() ([]byte, []int) {
+	return file_eventstore_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ReadStreamRequest) GetStream() string {
+	if x != nil {
+		return x.Stream
+	}
+	return ""
+}
+
+type ReadStreamAtRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stream        string                 `protobuf:"bytes,1,opt,name=stream,proto3" json:"stream,omitempty"`
+	At            *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=at,proto3" json:"at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadStreamAtRequest) Reset() {
+	*x = ReadStreamAtRequest{}
+	mi := &file_eventstore_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadStreamAtRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadStreamAtRequest) ProtoMessage() {}
+
+func (x *ReadStreamAtRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_eventstore_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadStreamAtRequest.ProtoReflect.Descriptor instead.
+func (*ReadStreamAtRequest) Descriptor() ([]byte, []int) {
+	return file_eventstore_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ReadStreamAtRequest) GetStream() string {
+	if x != nil {
+		return x.Stream
+	}
+	return ""
+}
+
+func (x *ReadStreamAtRequest) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+type ReadStreamResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*Event               `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadStreamResponse) Reset() {
+	*x = ReadStreamResponse{}
+	mi := &file_eventstore_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadStreamResponse) ProtoMessage() {}
+
+func (x *ReadStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_eventstore_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadStreamResponse.ProtoReflect.Descriptor instead.
+func (*ReadStreamResponse) Descriptor() ([]byte, []int) {
+	return file_eventstore_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ReadStreamResponse) GetEvents() []*Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type SubscribeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// resume offset, the global position of the first entry to receive
+	Offset        *uint64 `protobuf:"varint,1,opt,name=offset,proto3,oneof" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_eventstore_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_eventstore_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_eventstore_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SubscribeRequest) GetOffset() uint64 {
+	if x != nil && x.Offset != nil {
+		return *x.Offset
+	}
+	return 0
+}
+
+type SubscribeResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Entries []*Entry               `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	// phase of the subscription after these entries, a response without entries
+	// reports the subscription went live
+	Phase         Phase `protobuf:"varint,2,opt,name=phase,proto3,enum=evently.eventstore.v1.Phase" json:"phase,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeResponse) Reset() {
+	*x = SubscribeResponse{}
+	mi := &file_eventstore_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeResponse) ProtoMessage() {}
+
+func (x *SubscribeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_eventstore_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeResponse.ProtoReflect.Descriptor instead.
+func (*SubscribeResponse) Descriptor() ([]byte, []int) {
+	return file_eventstore_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SubscribeResponse) GetEntries() []*Entry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *SubscribeResponse) GetPhase() Phase {
+	if x != nil {
+		return x.Phase
+	}
+	return Phase_PHASE_CATCH_UP
+}
+
+// Error is attached to the status of a failed call as detail and carries the
+// code of the evently.Error
+type Error struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          uint32                 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Text          string                 `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+	Cause         string                 `protobuf:"bytes,4,opt,name=cause,proto3" json:"cause,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_eventstore_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_eventstore_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_eventstore_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Error) GetCode() uint32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *Error) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Error) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Error) GetCause() string {
+	if x != nil {
+		return x.Cause
+	}
+	return ""
+}
+
+var File_eventstore_proto protoreflect.FileDescriptor
+
+const file_eventstore_proto_rawDesc = "" +
+	"\n" +
+	"\x10eventstore.proto\x12\x15evently.eventstore.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xfb\x02\n" +
+	"\x05Event\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12!\n" +
+	"\faggregate_id\x18\x04 \x01(\tR\vaggregateId\x12\x18\n" +
+	"\apayload\x18\x05 \x01(\fR\apayload\x12F\n" +
+	"\bmetadata\x18\x06 \x03(\v2*.evently.eventstore.v1.Event.MetadataEntryR\bmetadata\x12;\n" +
+	"\voccurred_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\x12;\n" +
+	"\vrecorded_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"recordedAt\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x8c\x01\n" +
+	"\x05Entry\x12\x1d\n" +
+	"\n" +
+	"global_pos\x18\x01 \x01(\x04R\tglobalPos\x12\x16\n" +
+	"\x06stream\x18\x02 \x01(\tR\x06stream\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x04R\aversion\x122\n" +
+	"\x05event\x18\x04 \x01(\v2\x1c.evently.eventstore.v1.EventR\x05event\"\x90\x01\n" +
+	"\x15AppendToStreamRequest\x12\x16\n" +
+	"\x06stream\x18\x01 \x01(\tR\x06stream\x12)\n" +
+	"\x10expected_version\x18\x02 \x01(\x04R\x0fexpectedVersion\x124\n" +
+	"\x06events\x18\x03 \x03(\v2\x1c.evently.eventstore.v1.EventR\x06events\"\x18\n" +
+	"\x16AppendToStreamResponse\"+\n" +
+	"\x11ReadStreamRequest\x12\x16\n" +
+	"\x06stream\x18\x01 \x01(\tR\x06stream\"Y\n" +
+	"\x13ReadStreamAtRequest\x12\x16\n" +
+	"\x06stream\x18\x01 \x01(\tR\x06stream\x12*\n" +
+	"\x02at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\"J\n" +
+	"\x12ReadStreamResponse\x124\n" +
+	"\x06events\x18\x01 \x03(\v2\x1c.evently.eventstore.v1.EventR\x06events\":\n" +
+	"\x10SubscribeRequest\x12\x1b\n" +
+	"\x06offset\x18\x01 \x01(\x04H\x00R\x06offset\x88\x01\x01B\t\n" +
+	"\a_offset\"\x7f\n" +
+	"\x11SubscribeResponse\x126\n" +
+	"\aentries\x18\x01 \x03(\v2\x1c.evently.eventstore.v1.EntryR\aentries\x122\n" +
+	"\x05phase\x18\x02 \x01(\x0e2\x1c.evently.eventstore.v1.PhaseR\x05phase\"Y\n" +
+	"\x05Error\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\rR\x04code\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04text\x18\x03 \x01(\tR\x04text\x12\x14\n" +
+	"\x05cause\x18\x04 \x01(\tR\x05cause*+\n" +
+	"\x05Phase\x12\x12\n" +
+	"\x0ePHASE_CATCH_UP\x10\x00\x12\x0e\n" +
+	"\n" +
+	"PHASE_LIVE\x10\x012\xa7\x03\n" +
+	"\n" +
+	"EventStore\x12m\n" +
+	"\x0eAppendToStream\x12,.evently.eventstore.v1.AppendToStreamRequest\x1a-.evently.eventstore.v1.AppendToStreamResponse\x12a\n" +
+	"\n" +
+	"ReadStream\x12(.evently.eventstore.v1.ReadStreamRequest\x1a).evently.eventstore.v1.ReadStreamResponse\x12e\n" +
+	"\fReadStreamAt\x12*.evently.eventstore.v1.ReadStreamAtRequest\x1a).evently.eventstore.v1.ReadStreamResponse\x12`\n" +
+	"\tSubscribe\x12'.evently.eventstore.v1.SubscribeRequest\x1a(.evently.eventstore.v1.SubscribeResponse0\x01B2Z0github.com/openyard/evently/command/es/esgrpc/pbb\x06proto3"
+
+var (
+	file_eventstore_proto_rawDescOnce sync.Once
+	file_eventstore_proto_rawDescData []byte
+)
+
+func file_eventstore_proto_rawDescGZIP() []byte {
+	file_eventstore_proto_rawDescOnce.Do(func() {
+		file_eventstore_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_eventstore_proto_rawDesc), len(file_eventstore_proto_rawDesc)))
+	})
+	return file_eventstore_proto_rawDescData
+}
+
+var file_eventstore_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_eventstore_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_eventstore_proto_goTypes = []any{
+	(Phase)(0),                     // 0: evently.eventstore.v1.Phase
+	(*Event)(nil),                  // 1: evently.eventstore.v1.Event
+	(*Entry)(nil),                  // 2: evently.eventstore.v1.Entry
+	(*AppendToStreamRequest)(nil),  // 3: evently.eventstore.v1.AppendToStreamRequest
+	(*AppendToStreamResponse)(nil), // 4: evently.eventstore.v1.AppendToStreamResponse
+	(*ReadStreamRequest)(nil),      // 5: evently.eventstore.v1.ReadStreamRequest
+	(*ReadStreamAtRequest)(nil),    // 6: evently.eventstore.v1.ReadStreamAtRequest
+	(*ReadStreamResponse)(nil),     // 7: evently.eventstore.v1.ReadStreamResponse
+	(*SubscribeRequest)(nil),       // 8: evently.eventstore.v1.SubscribeRequest
+	(*SubscribeResponse)(nil),      // 9: evently.eventstore.v1.SubscribeResponse
+	(*Error)(nil),                  // 10: evently.eventstore.v1.Error
+	nil,                            // 11: evently.eventstore.v1.Event.MetadataEntry
+	(*timestamppb.Timestamp)(nil),  // 12: google.protobuf.Timestamp
+}
+var file_eventstore_proto_depIdxs = []int32{
+	11, // 0: evently.eventstore.v1.Event.metadata:type_name -> evently.eventstore.v1.Event.MetadataEntry
+	12, // 1: evently.eventstore.v1.Event.occurred_at:type_name -> google.protobuf.Timestamp
+	12, // 2: evently.eventstore.v1.Event.recorded_at:type_name -> google.protobuf.Timestamp
+	1,  // 3: evently.eventstore.v1.Entry.event:type_name -> evently.eventstore.v1.Event
+	1,  // 4: evently.eventstore.v1.AppendToStreamRequest.events:type_name -> evently.eventstore.v1.Event
+	12, // 5: evently.eventstore.v1.ReadStreamAtRequest.at:type_name -> google.protobuf.Timestamp
+	1,  // 6: evently.eventstore.v1.ReadStreamResponse.events:type_name -> evently.eventstore.v1.Event
+	2,  // 7: evently.eventstore.v1.SubscribeResponse.entries:type_name -> evently.eventstore.v1.Entry
+	0,  // 8: evently.eventstore.v1.SubscribeResponse.phase:type_name -> evently.eventstore.v1.Phase
+	3,  // 9: evently.eventstore.v1.EventStore.AppendToStream:input_type -> evently.eventstore.v1.AppendToStreamRequest
+	5,  // 10: evently.eventstore.v1.EventStore.ReadStream:input_type -> evently.eventstore.v1.ReadStreamRequest
+	6,  // 11: evently.eventstore.v1.EventStore.ReadStreamAt:input_type -> evently.eventstore.v1.ReadStreamAtRequest
+	8,  // 12: evently.eventstore.v1.EventStore.Subscribe:input_type -> evently.eventstore.v1.SubscribeRequest
+	4,  // 13: evently.eventstore.v1.EventStore.AppendToStream:output_type -> evently.eventstore.v1.AppendToStreamResponse
+	7,  // 14: evently.eventstore.v1.EventStore.ReadStream:output_type -> evently.eventstore.v1.ReadStreamResponse
+	7,  // 15: evently.eventstore.v1.EventStore.ReadStreamAt:output_type -> evently.eventstore.v1.ReadStreamResponse
+	9,  // 16: evently.eventstore.v1.EventStore.Subscribe:output_type -> evently.eventstore.v1.SubscribeResponse
+	13, // [13:17] is the sub-list for method output_type
+	9,  // [9:13] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_eventstore_proto_init() }
+func file_eventstore_proto_init() {
+	if File_eventstore_proto != nil {
+		return
+	}
+	file_eventstore_proto_msgTypes[7].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_eventstore_proto_rawDesc), len(file_eventstore_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_eventstore_proto_goTypes,
+		DependencyIndexes: file_eventstore_proto_depIdxs,
+		EnumInfos:         file_eventstore_proto_enumTypes,
+		MessageInfos:      file_eventstore_proto_msgTypes,
+	}.Build()
+	File_eventstore_proto = out.File
+	file_eventstore_proto_goTypes = nil
+	file_eventstore_proto_depIdxs = nil
+}
@@ -0,0 +1,4 @@
+// Package pb holds the protocol of the event store gRPC service generated from eventstore.proto
+package pb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative eventstore.proto
@@ -0,0 +1,113 @@
+// Package esgrpc shares an event store between processes over gRPC. The protocol is
+// defined in pb/eventstore.proto, so services in other languages can use it as well.
+// The Server exposes any es.EventStore and es.Transport, the Client implements
+// es.EventStore and es.Transport on top of it. It's a module of its own, so importers of
+// evently don't depend on gRPC.
+package esgrpc
+
+import (
+	"context"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/command/es/esgrpc/pb"
+	"google.golang.org/grpc"
+)
+
+var _ pb.EventStoreServer = (*Server)(nil)
+
+// Server implements the EventStore service for an es.EventStore. Subscribing requires
+// the store to implement es.Transport, otherwise it fails with es.ErrUnsupported.
+type Server struct {
+	pb.UnimplementedEventStoreServer
+	store es.EventStore
+}
+
+// NewServer returns a Server for the given store
+func NewServer(store es.EventStore) *Server {
+	return &Server{store: store}
+}
+
+// Register registers the Server at the given gRPC server
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	pb.RegisterEventStoreServer(registrar, s)
+}
+
+func (s *Server) AppendToStream(_ context.Context, req *pb.AppendToStreamRequest) (*pb.AppendToStreamResponse, error) {
+	if req.GetStream() == "" {
+		return nil, toStatus(evently.Errorf(ErrBadRequest, "ErrBadRequest", "missing stream"))
+	}
+	for i, e := range req.GetEvents() {
+		if e.GetId() == "" || e.GetName() == "" || e.GetAggregateId() == "" || e.GetOccurredAt() == nil {
+			return nil, toStatus(evently.Errorf(ErrBadRequest, "ErrBadRequest", "event %d without id, name, aggregate_id or occurred_at", i))
+		}
+	}
+	if err := s.store.AppendToStream(req.GetStream(), req.GetExpectedVersion(), fromEvents(req.GetEvents())...); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.AppendToStreamResponse{}, nil
+}
+
+func (s *Server) ReadStream(_ context.Context, req *pb.ReadStreamRequest) (*pb.ReadStreamResponse, error) {
+	history, err := s.store.ReadStream(req.GetStream())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ReadStreamResponse{Events: toEvents(history)}, nil
+}
+
+func (s *Server) ReadStreamAt(_ context.Context, req *pb.ReadStreamAtRequest) (*pb.ReadStreamResponse, error) {
+	if req.GetAt() == nil {
+		return nil, toStatus(evently.Errorf(ErrBadRequest, "ErrBadRequest", "missing at"))
+	}
+	history, err := s.store.ReadStreamAt(req.GetStream(), req.GetAt().AsTime())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ReadStreamResponse{Events: toEvents(history)}, nil
+}
+
+// Subscribe streams the entries of the store until the client cancels or the
+// subscription of the store fails
+func (s *Server) Subscribe(req *pb.SubscribeRequest, stream pb.EventStore_SubscribeServer) error {
+	transport, ok := s.store.(es.Transport)
+	if !ok {
+		return toStatus(evently.Errorf(es.ErrUnsupported, "ErrUnsupported", "[%T] subscriptions", s.store))
+	}
+	var sub es.Subscription
+	if req.Offset != nil {
+		sub = transport.SubscribeWithOffset(req.GetOffset())
+	} else {
+		sub = transport.Subscribe()
+	}
+	defer sub.Close()
+	live := sub.Live()
+	for {
+		select {
+		case entries, ok := <-sub.Entries():
+			if !ok {
+				if err := sub.Err(); err != nil {
+					return toStatus(err)
+				}
+				return nil
+			}
+			if err := stream.Send(&pb.SubscribeResponse{Entries: toEntries(entries), Phase: toPhase(sub.Phase())}); err != nil {
+				return err
+			}
+		case <-live:
+			live = nil
+			if err := stream.Send(&pb.SubscribeResponse{Phase: pb.Phase_PHASE_LIVE}); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func toPhase(p es.Phase) pb.Phase {
+	if p == es.Live {
+		return pb.Phase_PHASE_LIVE
+	}
+	return pb.Phase_PHASE_CATCH_UP
+}
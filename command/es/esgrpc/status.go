@@ -0,0 +1,70 @@
+package esgrpc
+
+import (
+	"errors"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/command/es/esgrpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statuses maps the codes of evently.Error to gRPC status codes, all other codes are
+// answered with codes.Internal
+var statuses = []struct {
+	code   uint
+	name   string
+	status codes.Code
+}{
+	{es.ErrConcurrentChange, "ErrConcurrentChange", codes.Aborted},
+	{es.ErrUnsupported, "ErrUnsupported", codes.Unimplemented},
+	{ErrBadRequest, "ErrBadRequest", codes.InvalidArgument},
+}
+
+// toStatus returns the gRPC status of the given error with the evently.Error as detail
+func toStatus(err error) error {
+	var e *evently.Error
+	if !errors.As(err, &e) {
+		e = evently.Errorf(ErrRemote, "ErrRemote", "%s", err.Error())
+	}
+	detail := &pb.Error{Code: uint32(e.Code), Name: e.Name, Text: e.Text}
+	if e.Cause != nil {
+		detail.Cause = e.Cause.Error()
+	}
+	code := codes.Internal
+	for _, s := range statuses {
+		if s.code == e.Code {
+			code = s.status
+		}
+	}
+	st, derr := status.New(code, e.Error()).WithDetails(detail)
+	if derr != nil {
+		return status.Error(code, e.Error())
+	}
+	return st.Err()
+}
+
+// fromStatus turns the gRPC status of a failed call back into an evently.Error. A status
+// without detail, e.g. of a broken connection, gets the code mapped from its status code.
+func fromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return evently.Errorf(ErrRequest, "ErrRequest", "call failed").CausedBy(err)
+	}
+	for _, detail := range st.Details() {
+		if d, ok := detail.(*pb.Error); ok {
+			e := &evently.Error{Code: uint(d.GetCode()), Name: d.GetName(), Text: d.GetText()}
+			if d.GetCause() != "" {
+				e.Cause = errors.New(d.GetCause())
+			}
+			return e
+		}
+	}
+	for _, s := range statuses {
+		if s.status == st.Code() {
+			return evently.Errorf(s.code, s.name, "%s", st.Message())
+		}
+	}
+	return evently.Errorf(ErrRequest, "ErrRequest", "%s: %s", st.Code(), st.Message())
+}
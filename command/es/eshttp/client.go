@@ -0,0 +1,178 @@
+package eshttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/event"
+)
+
+const (
+	defaultWait     = 30 * time.Second
+	defaultPageSize = 1000
+)
+
+var _ es.EventStore = (*Client)(nil)
+var _ es.Transport = (*Client)(nil)
+var _ es.LogReader = (*Client)(nil)
+var _ es.HeadReader = (*Client)(nil)
+
+type ClientOption func(c *Client)
+
+// Client is an es.EventStore and es.Transport backed by a Server. Failed requests
+// return the evently.Error of the server, e.g. es.ErrConcurrentChange on conflicts.
+type Client struct {
+	baseURL  string
+	http     *http.Client
+	wait     time.Duration
+	pageSize int
+}
+
+// NewClient returns a Client of the Server at the given base URL
+func NewClient(baseURL string, opts ...ClientOption) *Client {
+	c := &Client{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		http:     http.DefaultClient,
+		wait:     defaultWait,
+		pageSize: defaultPageSize,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithHTTPClient sends the requests with the given http.Client instead of http.DefaultClient
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(c *Client) {
+		c.http = client
+	}
+}
+
+// WithWait sets the time a live subscription waits for new entries per request, default is 30s
+func WithWait(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.wait = d
+	}
+}
+
+// WithPageSize sets the maximum number of entries per request of a subscription, default is 1000
+func WithPageSize(n int) ClientOption {
+	return func(c *Client) {
+		c.pageSize = n
+	}
+}
+
+func (c *Client) ReadStream(stream string) (es.History, error) {
+	var history es.History
+	err := c.get(context.Background(), streamPath(stream), nil, &history)
+	return history, err
+}
+
+func (c *Client) ReadStreamAt(stream string, at time.Time) (es.History, error) {
+	var history es.History
+	err := c.get(context.Background(), streamPath(stream), url.Values{"at": {at.Format(time.RFC3339Nano)}}, &history)
+	return history, err
+}
+
+func (c *Client) AppendToStream(stream string, expectedVersion uint64, events ...*event.Event) error {
+	if events == nil {
+		events = []*event.Event{}
+	}
+	body, err := json.Marshal(events)
+	if err != nil {
+		return evently.Errorf(ErrRequest, "ErrRequest", "encode events of stream %q", stream).CausedBy(err)
+	}
+	query := url.Values{"expectedVersion": {strconv.FormatUint(expectedVersion, 10)}}
+	req, err := http.NewRequest(http.MethodPost, c.url(streamPath(stream), query), bytes.NewReader(body))
+	if err != nil {
+		return evently.Errorf(ErrRequest, "ErrRequest", "append to stream %q", stream).CausedBy(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, nil)
+}
+
+// ReadLog returns up to max entries of the global log starting at the given global position
+func (c *Client) ReadLog(offset uint64, max int) ([]*es.Entry, error) {
+	return c.readLog(context.Background(), offset, max, 0)
+}
+
+// Head returns the global position the next appended entry will get
+func (c *Client) Head() (uint64, error) {
+	var head uint64
+	err := c.get(context.Background(), "/head", nil, &head)
+	return head, err
+}
+
+// Subscribe listens for new entries starting at the head of the global log, which
+// requires the store of the Server to implement es.HeadReader
+func (c *Client) Subscribe() es.Subscription {
+	return c.subscribe(func() (uint64, error) { return c.Head() })
+}
+
+// SubscribeWithOffset fetches remaining entries based on given offset and listens for
+// new entries by long-polling the global log
+func (c *Client) SubscribeWithOffset(offset uint64) es.Subscription {
+	return c.subscribe(func() (uint64, error) { return offset, nil })
+}
+
+func (c *Client) readLog(ctx context.Context, offset uint64, max int, wait time.Duration) ([]*es.Entry, error) {
+	query := url.Values{
+		"offset": {strconv.FormatUint(offset, 10)},
+		"max":    {strconv.Itoa(max)},
+	}
+	if wait > 0 {
+		query.Set("wait", wait.String())
+	}
+	var entries []*es.Entry
+	err := c.get(ctx, "/log", query, &entries)
+	return entries, err
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(path, query), nil)
+	if err != nil {
+		return evently.Errorf(ErrRequest, "ErrRequest", "GET %s", path).CausedBy(err)
+	}
+	return c.do(req, v)
+}
+
+// do sends the request and decodes the response into v, if v isn't nil
+func (c *Client) do(req *http.Request, v any) error {
+	res, err := c.http.Do(req)
+	if err != nil {
+		return evently.Errorf(ErrRequest, "ErrRequest", "%s %s", req.Method, req.URL.Path).CausedBy(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		return readError(res)
+	}
+	if v == nil {
+		_, _ = io.Copy(io.Discard, res.Body)
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return evently.Errorf(ErrRequest, "ErrRequest", "decode response of %s %s", req.Method, req.URL.Path).CausedBy(err)
+	}
+	return nil
+}
+
+func (c *Client) url(path string, query url.Values) string {
+	if len(query) == 0 {
+		return c.baseURL + path
+	}
+	return c.baseURL + path + "?" + query.Encode()
+}
+
+func streamPath(stream string) string {
+	return "/streams/" + url.PathEscape(stream)
+}
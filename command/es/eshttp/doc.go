@@ -0,0 +1,15 @@
+// Package eshttp shares an event store between processes over HTTP. The Server exposes
+// any es.EventStore and es.Transport as JSON API, the Client implements es.EventStore
+// and es.Transport on top of it, so services can't tell a remote from a local store.
+//
+// The API consists of the following endpoints:
+//
+//	GET  /streams/{stream}                        read the stream
+//	GET  /streams/{stream}?at={RFC3339}           read the stream at a point in time
+//	POST /streams/{stream}?expectedVersion={n}    append the events of the body
+//	GET  /log?offset={n}&max={n}&wait={duration}  read the global log, wait for new entries if empty
+//	GET  /head                                    read the head of the global log
+//
+// Failures are answered with the HTTP status mapped from the code of the evently.Error
+// and the error itself as JSON body. The Client turns it back into an evently.Error.
+package eshttp
@@ -0,0 +1,8 @@
+package eshttp
+
+// error codes
+const (
+	ErrBadRequest = iota + 9501
+	ErrRequest
+	ErrRemote
+)
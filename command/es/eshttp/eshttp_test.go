@@ -0,0 +1,110 @@
+package eshttp_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/command/es/eshttp"
+	"github.com/openyard/evently/command/es/estest"
+	"github.com/openyard/evently/event"
+)
+
+func TestClient_Conformance(t *testing.T) {
+	estest.TestConformance(t, func() es.EventStore {
+		server := httptest.NewServer(eshttp.NewServer(estest.NewTestEventStore()))
+		t.Cleanup(server.Close)
+		return eshttp.NewClient(server.URL, eshttp.WithWait(100*time.Millisecond))
+	})
+}
+
+func TestClient_errors(t *testing.T) {
+	server := httptest.NewServer(eshttp.NewServer(streamsOnly{estest.NewTestEventStore()}))
+	defer server.Close()
+	client := eshttp.NewClient(server.URL)
+
+	_ = client.AppendToStream("customer-1", 0, event.NewDomainEvent("created", "1"))
+	err := client.AppendToStream("customer-1", 0, event.NewDomainEvent("created", "1"))
+	assertCode(t, err, es.ErrConcurrentChange)
+	_, err = client.ReadLog(0, 10)
+	assertCode(t, err, es.ErrUnsupported)
+	_, err = client.Head()
+	assertCode(t, err, es.ErrUnsupported)
+
+	res, err := http.Post(server.URL+"/streams/customer-1?expectedVersion=x", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, res.StatusCode)
+	}
+	res, err = http.Post(server.URL+"/streams/customer-1?expectedVersion=1", "application/json", strings.NewReader(`[{"ID":"1"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status %d for a malformed event, got %d", http.StatusBadRequest, res.StatusCode)
+	}
+}
+
+func TestClient_Subscribe(t *testing.T) {
+	store := estest.NewTestEventStore()
+	server := httptest.NewServer(eshttp.NewServer(store))
+	defer server.Close()
+	client := eshttp.NewClient(server.URL, eshttp.WithPageSize(2))
+	_ = store.AppendToStream("customer-1", 0, event.NewDomainEvent("created", "1"))
+
+	s := client.Subscribe()
+	defer s.Close()
+	select {
+	case <-s.Live():
+	case <-time.After(time.Second):
+		t.Fatalf("subscription not live, phase is %s", s.Phase())
+	}
+	_ = store.AppendToStream("customer-1", 1, event.NewDomainEvent("activated", "1"))
+	select {
+	case entries := <-s.Entries():
+		if len(entries) != 1 || entries[0].Event.Name() != "activated" || entries[0].GlobalPos != 1 {
+			t.Errorf("unexpected entries %+v", entries)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no live entries received")
+	}
+}
+
+func TestClient_SubscribeWithOffset_failure(t *testing.T) {
+	server := httptest.NewServer(eshttp.NewServer(streamsOnly{estest.NewTestEventStore()}))
+	defer server.Close()
+
+	s := eshttp.NewClient(server.URL).SubscribeWithOffset(0)
+	defer s.Close()
+	select {
+	case _, ok := <-s.Entries():
+		if ok {
+			t.Fatal("expected the subscription to end")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription didn't end")
+	}
+	assertCode(t, s.Err(), es.ErrUnsupported)
+}
+
+func assertCode(t *testing.T, err error, code uint) {
+	t.Helper()
+	var e *evently.Error
+	if !errors.As(err, &e) || e.Code != code {
+		t.Errorf("expected error code %d, got %v", code, err)
+	}
+}
+
+// streamsOnly hides all but the es.EventStore methods of the store
+type streamsOnly struct {
+	es.EventStore
+}
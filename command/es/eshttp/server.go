@@ -0,0 +1,245 @@
+package eshttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/event"
+)
+
+const (
+	defaultMaxWait    = 30 * time.Second
+	defaultMaxEntries = 1000
+	pollInterval      = 100 * time.Millisecond
+)
+
+var _ http.Handler = (*Server)(nil)
+
+type ServerOption func(s *Server)
+
+// Server exposes an es.EventStore as JSON API. Reading the global log requires the store
+// to implement es.LogReader, waiting for new entries es.Transport and reading the head
+// es.HeadReader. Otherwise, these requests fail with es.ErrUnsupported.
+type Server struct {
+	store      es.EventStore
+	mux        *http.ServeMux
+	maxWait    time.Duration
+	maxEntries int
+}
+
+// NewServer returns a Server for the given store
+func NewServer(store es.EventStore, opts ...ServerOption) *Server {
+	s := &Server{
+		store:      store,
+		mux:        http.NewServeMux(),
+		maxWait:    defaultMaxWait,
+		maxEntries: defaultMaxEntries,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.mux.HandleFunc("/streams/", s.handleStream)
+	s.mux.HandleFunc("/log", s.handleLog)
+	s.mux.HandleFunc("/head", s.handleHead)
+	return s
+}
+
+// WithMaxWait limits the time a request of the global log waits for new entries, default is 30s
+func WithMaxWait(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.maxWait = d
+	}
+}
+
+// WithMaxEntries limits the number of entries per response of the global log, default is 1000
+func WithMaxEntries(n int) ServerOption {
+	return func(s *Server) {
+		s.maxEntries = n
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	stream := strings.TrimPrefix(r.URL.Path, "/streams/")
+	if stream == "" {
+		writeError(w, evently.Errorf(ErrBadRequest, "ErrBadRequest", "missing stream"))
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		s.readStream(w, r, stream)
+	case http.MethodPost:
+		s.appendToStream(w, r, stream)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, evently.Errorf(ErrBadRequest, "ErrBadRequest", "method %s not allowed", r.Method))
+	}
+}
+
+func (s *Server) readStream(w http.ResponseWriter, r *http.Request, stream string) {
+	var history es.History
+	var err error
+	if at := r.URL.Query().Get("at"); at != "" {
+		t, perr := time.Parse(time.RFC3339Nano, at)
+		if perr != nil {
+			writeError(w, evently.Errorf(ErrBadRequest, "ErrBadRequest", "invalid at %q", at).CausedBy(perr))
+			return
+		}
+		history, err = s.store.ReadStreamAt(stream, t)
+	} else {
+		history, err = s.store.ReadStream(stream)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if history == nil {
+		history = es.History{}
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+func (s *Server) appendToStream(w http.ResponseWriter, r *http.Request, stream string) {
+	expectedVersion, err := strconv.ParseUint(r.URL.Query().Get("expectedVersion"), 10, 64)
+	if err != nil {
+		writeError(w, evently.Errorf(ErrBadRequest, "ErrBadRequest", "invalid expectedVersion").CausedBy(err))
+		return
+	}
+	var events []*event.Event
+	if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+		writeError(w, evently.Errorf(ErrBadRequest, "ErrBadRequest", "invalid events").CausedBy(err))
+		return
+	}
+	if err := s.store.AppendToStream(stream, expectedVersion, events...); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeError(w, evently.Errorf(ErrBadRequest, "ErrBadRequest", "method %s not allowed", r.Method))
+		return
+	}
+	query := r.URL.Query()
+	offset, err := parseUint(query.Get("offset"))
+	if err != nil {
+		writeError(w, evently.Errorf(ErrBadRequest, "ErrBadRequest", "invalid offset").CausedBy(err))
+		return
+	}
+	max, err := parseUint(query.Get("max"))
+	if err != nil {
+		writeError(w, evently.Errorf(ErrBadRequest, "ErrBadRequest", "invalid max").CausedBy(err))
+		return
+	}
+	if max == 0 || max > uint64(s.maxEntries) {
+		max = uint64(s.maxEntries)
+	}
+	var wait time.Duration
+	if v := query.Get("wait"); v != "" {
+		if wait, err = time.ParseDuration(v); err != nil {
+			writeError(w, evently.Errorf(ErrBadRequest, "ErrBadRequest", "invalid wait").CausedBy(err))
+			return
+		}
+	}
+	if wait > s.maxWait {
+		wait = s.maxWait
+	}
+	entries, err := s.readLog(r, offset, int(max), wait)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if entries == nil {
+		entries = []*es.Entry{}
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// readLog reads the global log. If there are no entries and the request wants to wait,
+// readLog waits for new entries by subscribing the store at the offset or, if the store
+// isn't an es.Transport, by reading the log repeatedly.
+func (s *Server) readLog(r *http.Request, offset uint64, max int, wait time.Duration) ([]*es.Entry, error) {
+	reader, isReader := s.store.(es.LogReader)
+	transport, isTransport := s.store.(es.Transport)
+	if !isReader && (!isTransport || wait <= 0) {
+		return nil, evently.Errorf(es.ErrUnsupported, "ErrUnsupported", "[%T] reading the global log", s.store)
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	if !isTransport {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			entries, err := reader.ReadLog(offset, max)
+			if err != nil || len(entries) > 0 || wait <= 0 {
+				return entries, err
+			}
+			select {
+			case <-ticker.C:
+			case <-timer.C:
+				return nil, nil
+			case <-r.Context().Done():
+				return nil, nil
+			}
+		}
+	}
+	if isReader {
+		entries, err := reader.ReadLog(offset, max)
+		if err != nil || len(entries) > 0 || wait <= 0 {
+			return entries, err
+		}
+	}
+	sub := transport.SubscribeWithOffset(offset)
+	defer sub.Close()
+	select {
+	case entries, ok := <-sub.Entries():
+		if !ok {
+			return nil, sub.Err()
+		}
+		if len(entries) > max {
+			entries = entries[:max]
+		}
+		return entries, nil
+	case <-timer.C:
+		return nil, nil
+	case <-r.Context().Done():
+		return nil, nil
+	}
+}
+
+func (s *Server) handleHead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeError(w, evently.Errorf(ErrBadRequest, "ErrBadRequest", "method %s not allowed", r.Method))
+		return
+	}
+	reader, ok := s.store.(es.HeadReader)
+	if !ok {
+		writeError(w, evently.Errorf(es.ErrUnsupported, "ErrUnsupported", "[%T] reading the head", s.store))
+		return
+	}
+	head, err := reader.Head()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, head)
+}
+
+func parseUint(v string) (uint64, error) {
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(v, 10, 64)
+}
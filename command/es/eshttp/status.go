@@ -0,0 +1,78 @@
+package eshttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+)
+
+// statuses maps the codes of evently.Error to HTTP statuses, all other codes are
+// answered with http.StatusInternalServerError
+var statuses = []struct {
+	code   uint
+	name   string
+	status int
+}{
+	{es.ErrConcurrentChange, "ErrConcurrentChange", http.StatusConflict},
+	{es.ErrUnsupported, "ErrUnsupported", http.StatusNotImplemented},
+	{ErrBadRequest, "ErrBadRequest", http.StatusBadRequest},
+}
+
+// remoteError is the JSON body of a failed request
+type remoteError struct {
+	Code  uint
+	Name  string
+	Text  string
+	Cause string `json:",omitempty"`
+}
+
+// writeError answers the request with the status and body of the given error
+func writeError(w http.ResponseWriter, err error) {
+	var e *evently.Error
+	if !errors.As(err, &e) {
+		e = evently.Errorf(ErrRemote, "ErrRemote", "%s", err.Error())
+	}
+	body := remoteError{Code: e.Code, Name: e.Name, Text: e.Text}
+	if e.Cause != nil {
+		body.Cause = e.Cause.Error()
+	}
+	status := http.StatusInternalServerError
+	for _, s := range statuses {
+		if s.code == e.Code {
+			status = s.status
+		}
+	}
+	writeJSON(w, status, body)
+}
+
+// readError turns a failed response back into an evently.Error. Responses without error
+// body, e.g. from a proxy, get the code mapped from the HTTP status.
+func readError(res *http.Response) error {
+	data, _ := io.ReadAll(res.Body)
+	var body remoteError
+	if err := json.Unmarshal(data, &body); err == nil && body.Code != 0 {
+		e := &evently.Error{Code: body.Code, Name: body.Name, Text: body.Text}
+		if body.Cause != "" {
+			e.Cause = errors.New(body.Cause)
+		}
+		return e
+	}
+	text := fmt.Sprintf("%s: %s", res.Status, data)
+	for _, s := range statuses {
+		if s.status == res.StatusCode {
+			return evently.Errorf(s.code, s.name, "%s", text)
+		}
+	}
+	return evently.Errorf(ErrRemote, "ErrRemote", "%s", text)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
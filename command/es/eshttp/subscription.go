@@ -0,0 +1,104 @@
+package eshttp
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/openyard/evently/command/es"
+)
+
+// subscription polls the global log of a Server. While catching up it reads pages
+// without waiting, once a page isn't full it's live and waits for new entries.
+type subscription struct {
+	client    *Client
+	out       chan []*es.Entry
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+
+	phase    atomic.Int32
+	caughtUp chan struct{}
+	err      atomic.Value
+}
+
+func (c *Client) subscribe(offset func() (uint64, error)) *subscription {
+	s := &subscription{
+		client:   c,
+		out:      make(chan []*es.Entry),
+		caughtUp: make(chan struct{}),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	go s.run(offset)
+	return s
+}
+
+func (s *subscription) Entries() <-chan []*es.Entry {
+	return s.out
+}
+
+func (s *subscription) Err() error {
+	if err, ok := s.err.Load().(error); ok {
+		return err
+	}
+	return nil
+}
+
+func (s *subscription) Phase() es.Phase {
+	return es.Phase(s.phase.Load())
+}
+
+func (s *subscription) Live() <-chan struct{} {
+	return s.caughtUp
+}
+
+func (s *subscription) Close() {
+	s.closeOnce.Do(s.cancel)
+}
+
+func (s *subscription) run(start func() (uint64, error)) {
+	defer close(s.out)
+	defer s.Close()
+	offset, err := start()
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	var caughtOnce sync.Once
+	for {
+		var wait = s.client.wait
+		if s.Phase() == es.CatchUp {
+			wait = 0
+		}
+		entries, err := s.client.readLog(s.ctx, offset, s.client.pageSize, wait)
+		if err != nil {
+			s.fail(err)
+			return
+		}
+		if len(entries) < s.client.pageSize && s.Phase() == es.CatchUp {
+			s.phase.Store(int32(es.Live))
+			caughtOnce.Do(func() { close(s.caughtUp) })
+		} else if len(entries) == s.client.pageSize {
+			s.phase.Store(int32(es.CatchUp))
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		if s.ctx.Err() != nil {
+			return
+		}
+		select {
+		case s.out <- entries:
+			offset = entries[len(entries)-1].GlobalPos + 1
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// fail ends the subscription with the given error unless it was closed
+func (s *subscription) fail(err error) {
+	if s.ctx.Err() == nil {
+		s.err.Store(err)
+	}
+}
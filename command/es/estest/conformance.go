@@ -0,0 +1,372 @@
+package estest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/event"
+)
+
+const defaultConformanceTimeout = time.Second
+
+// Factory returns a new and empty store under test. The store must implement
+// es.EventStore. If it also implements es.Transport or es.MultiEventStore, these
+// behaviours are tested as well.
+type Factory func() es.EventStore
+
+// ConformanceOption configures a conformance run
+type ConformanceOption func(c *conformance)
+
+// WithDeliveryTimeout sets the time a subscriber waits for entries, default is 1s
+func WithDeliveryTimeout(d time.Duration) ConformanceOption {
+	return func(c *conformance) {
+		c.timeout = d
+	}
+}
+
+// SkipConformance skips the tests with the given names, e.g. "Transport/Live"
+func SkipConformance(names ...string) ConformanceOption {
+	return func(c *conformance) {
+		for _, name := range names {
+			c.skip[name] = true
+		}
+	}
+}
+
+type conformance struct {
+	factory Factory
+	timeout time.Duration
+	skip    map[string]bool
+}
+
+// TestConformance runs the behavioural specification every es.EventStore, es.Transport
+// and es.MultiEventStore implementation must satisfy
+func TestConformance(t *testing.T, factory Factory, opts ...ConformanceOption) {
+	c := &conformance{factory: factory, timeout: defaultConformanceTimeout, skip: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	t.Run("EventStore", func(t *testing.T) {
+		c.run(t, "EventStore", "ReadStreamEmpty", c.testReadStreamEmpty)
+		c.run(t, "EventStore", "Ordering", c.testOrdering)
+		c.run(t, "EventStore", "OptimisticConcurrency", c.testOptimisticConcurrency)
+		c.run(t, "EventStore", "ConcurrentAppenders", c.testConcurrentAppenders)
+		c.run(t, "EventStore", "ReadStreamAtBoundaries", c.testReadStreamAtBoundaries)
+	})
+	t.Run("Transport", func(t *testing.T) {
+		if _, ok := factory().(es.Transport); !ok {
+			t.Skip("store doesn't implement es.Transport")
+		}
+		c.run(t, "Transport", "Offset", c.testOffset)
+		c.run(t, "Transport", "Live", c.testLive)
+		c.run(t, "Transport", "MultipleSubscribers", c.testMultipleSubscribers)
+		c.run(t, "Transport", "SlowSubscriber", c.testSlowSubscriber)
+		c.run(t, "Transport", "Phase", c.testPhase)
+		c.run(t, "Transport", "Close", c.testClose)
+	})
+	t.Run("MultiEventStore", func(t *testing.T) {
+		if _, ok := factory().(es.MultiEventStore); !ok {
+			t.Skip("store doesn't implement es.MultiEventStore")
+		}
+		c.run(t, "MultiEventStore", "AppendMulti", c.testAppendMulti)
+		c.run(t, "MultiEventStore", "Atomicity", c.testAppendMultiAtomicity)
+	})
+}
+
+func (c *conformance) run(t *testing.T, group, name string, test func(t *testing.T, store es.EventStore)) {
+	t.Run(name, func(t *testing.T) {
+		if c.skip[group+"/"+name] {
+			t.Skip("skipped by SkipConformance")
+		}
+		test(t, c.factory())
+	})
+}
+
+func (c *conformance) testReadStreamEmpty(t *testing.T, store es.EventStore) {
+	history, err := store.ReadStream("unknown")
+	if err != nil {
+		t.Fatalf("read unknown stream: %s", err)
+	}
+	if len(history) != 0 {
+		t.Errorf("unknown stream has %d events", len(history))
+	}
+}
+
+func (c *conformance) testOrdering(t *testing.T, store es.EventStore) {
+	var expected []string
+	for version := uint64(0); version < 6; version += 2 {
+		first, second := event.NewDomainEvent("first", "1"), event.NewDomainEvent("second", "1")
+		expected = append(expected, first.ID(), second.ID())
+		mustAppend(t, store, "ordered", version, first, second)
+	}
+	history, err := store.ReadStream("ordered")
+	if err != nil {
+		t.Fatalf("read stream: %s", err)
+	}
+	assertIDs(t, history, expected)
+}
+
+func (c *conformance) testOptimisticConcurrency(t *testing.T, store es.EventStore) {
+	mustAppend(t, store, "concurrent", 0, event.NewDomainEvent("created", "1"))
+	for _, version := range []uint64{0, 2} {
+		err := store.AppendToStream("concurrent", version, event.NewDomainEvent("changed", "1"))
+		var e *evently.Error
+		if !errors.As(err, &e) || e.Code != es.ErrConcurrentChange {
+			t.Errorf("append with expectedVersion %d: expected ErrConcurrentChange, got %v", version, err)
+		}
+	}
+	history, _ := store.ReadStream("concurrent")
+	if len(history) != 1 {
+		t.Errorf("rejected appends changed the stream: %d events", len(history))
+	}
+}
+
+func (c *conformance) testConcurrentAppenders(t *testing.T, store es.EventStore) {
+	const appenders, appends = 8, 10
+	var wg sync.WaitGroup
+	for i := 0; i < appenders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; n < appends; {
+				history, err := store.ReadStream("contended")
+				if err != nil {
+					t.Errorf("read stream: %s", err)
+					return
+				}
+				err = store.AppendToStream("contended", uint64(len(history)), event.NewDomainEvent("appended", fmt.Sprint(i)))
+				var e *evently.Error
+				if errors.As(err, &e) && e.Code == es.ErrConcurrentChange {
+					continue
+				}
+				if err != nil {
+					t.Errorf("append: %s", err)
+					return
+				}
+				n++
+			}
+		}(i)
+	}
+	wg.Wait()
+	history, _ := store.ReadStream("contended")
+	if len(history) != appenders*appends {
+		t.Errorf("lost appends: %d events, expected %d", len(history), appenders*appends)
+	}
+	seen := make(map[string]bool)
+	for _, e := range history {
+		if seen[e.ID()] {
+			t.Errorf("event %s stored twice", e.ID())
+		}
+		seen[e.ID()] = true
+	}
+}
+
+func (c *conformance) testReadStreamAtBoundaries(t *testing.T, store es.EventStore) {
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2, t3 := t1.Add(time.Hour), t1.Add(2*time.Hour)
+	e1, e2, e3 := event.NewEventAt("e1", "1", t1), event.NewEventAt("e2", "1", t2), event.NewEventAt("e3", "1", t3)
+	mustAppend(t, store, "temporal", 0, e1, e2, e3)
+	tests := []struct {
+		at       time.Time
+		expected []string
+	}{
+		{t1, nil},
+		{t1.Add(time.Nanosecond), []string{e1.ID()}},
+		{t2, []string{e1.ID()}},
+		{t3.Add(time.Nanosecond), []string{e1.ID(), e2.ID(), e3.ID()}},
+	}
+	for _, test := range tests {
+		history, err := store.ReadStreamAt("temporal", test.at)
+		if err != nil {
+			t.Fatalf("read stream at %s: %s", test.at, err)
+		}
+		assertIDs(t, history, test.expected)
+	}
+}
+
+func (c *conformance) testOffset(t *testing.T, store es.EventStore) {
+	for i := uint64(0); i < 5; i++ {
+		mustAppend(t, store, "offset", i, event.NewDomainEvent("appended", "1"))
+	}
+	s := store.(es.Transport).SubscribeWithOffset(0)
+	defer s.Close()
+	all := c.receive(t, s, 5)
+	assertAscending(t, all)
+	offset := all[2].GlobalPos
+	s = store.(es.Transport).SubscribeWithOffset(offset)
+	defer s.Close()
+	rest := c.receive(t, s, 3)
+	assertAscending(t, rest)
+	if rest[0].GlobalPos != offset {
+		t.Errorf("subscription with offset %d started at %d", offset, rest[0].GlobalPos)
+	}
+}
+
+func (c *conformance) testLive(t *testing.T, store es.EventStore) {
+	mustAppend(t, store, "live", 0, event.NewDomainEvent("before", "1"))
+	s := store.(es.Transport).SubscribeWithOffset(0)
+	defer s.Close()
+	mustAppend(t, store, "live", 1, event.NewDomainEvent("after", "1"))
+	received := c.receive(t, s, 2)
+	assertAscending(t, received)
+	if received[1].Event.Name() != "after" {
+		t.Errorf("unexpected live event %q", received[1].Event.Name())
+	}
+}
+
+func (c *conformance) testMultipleSubscribers(t *testing.T, store es.EventStore) {
+	transport := store.(es.Transport)
+	first, second := transport.SubscribeWithOffset(0), transport.SubscribeWithOffset(0)
+	mustAppend(t, store, "broadcast", 0, event.NewDomainEvent("first", "1"), event.NewDomainEvent("second", "1"))
+	defer first.Close()
+	defer second.Close()
+	for _, s := range []es.Subscription{first, second} {
+		assertAscending(t, c.receive(t, s, 2))
+	}
+}
+
+func (c *conformance) testSlowSubscriber(t *testing.T, store es.EventStore) {
+	slow := store.(es.Transport).SubscribeWithOffset(0)
+	defer slow.Close()
+	const appends = 100
+	appended := make(chan struct{})
+	go func() {
+		defer close(appended)
+		for i := uint64(0); i < appends; i++ {
+			if err := store.AppendToStream("slow", i, event.NewDomainEvent("appended", "1")); err != nil {
+				t.Errorf("append to %q: %s", "slow", err)
+				return
+			}
+		}
+	}()
+	select {
+	case <-appended:
+	case <-time.After(c.timeout):
+		t.Fatal("a slow subscriber blocks appends")
+	}
+	received := c.receive(t, slow, appends)
+	assertAscending(t, received)
+}
+
+func (c *conformance) testPhase(t *testing.T, store es.EventStore) {
+	for i := uint64(0); i < 3; i++ {
+		mustAppend(t, store, "phase", i, event.NewDomainEvent("appended", "1"))
+	}
+	s := store.(es.Transport).SubscribeWithOffset(0)
+	defer s.Close()
+	c.receive(t, s, 3)
+	select {
+	case <-s.Live():
+	case <-time.After(c.timeout):
+		t.Fatalf("subscription not live after catching up, phase is %s", s.Phase())
+	}
+	mustAppend(t, store, "phase", 3, event.NewDomainEvent("appended", "1"))
+	c.receive(t, s, 1)
+}
+
+// testClose closes subscriptions while they read the entries appended before, none of
+// them may be delivered after Close
+func (c *conformance) testClose(t *testing.T, store es.EventStore) {
+	for i := uint64(0); i < 20; i++ {
+		s := store.(es.Transport).SubscribeWithOffset(0)
+		s.Close()
+		mustAppend(t, store, "closed", i, event.NewDomainEvent("appended", "1"))
+		select {
+		case batch, ok := <-s.Entries():
+			if ok {
+				t.Fatalf("received %d entries after close", len(batch))
+			}
+		case <-time.After(c.timeout):
+			t.Fatal("entries not closed by close")
+		}
+		if err := s.Err(); err != nil {
+			t.Errorf("closed subscription failed: %s", err)
+		}
+	}
+}
+
+func (c *conformance) testAppendMulti(t *testing.T, store es.EventStore) {
+	multi := store.(es.MultiEventStore)
+	mustAppend(t, store, "multi-2", 0, event.NewDomainEvent("created", "2"))
+	err := multi.AppendMulti(map[string]map[uint64][]*event.Event{
+		"multi-1": {0: {event.NewDomainEvent("created", "1")}},
+		"multi-2": {1: {event.NewDomainEvent("changed", "2")}},
+	})
+	if err != nil {
+		t.Fatalf("append multi: %s", err)
+	}
+	for stream, expected := range map[string]int{"multi-1": 1, "multi-2": 2} {
+		history, _ := store.ReadStream(stream)
+		if len(history) != expected {
+			t.Errorf("stream %q has %d events, expected %d", stream, len(history), expected)
+		}
+	}
+}
+
+func (c *conformance) testAppendMultiAtomicity(t *testing.T, store es.EventStore) {
+	multi := store.(es.MultiEventStore)
+	err := multi.AppendMulti(map[string]map[uint64][]*event.Event{
+		"atomic-1": {0: {event.NewDomainEvent("created", "1")}},
+		"atomic-2": {1: {event.NewDomainEvent("changed", "2")}},
+	})
+	var e *evently.Error
+	if !errors.As(err, &e) || e.Code != es.ErrConcurrentChange {
+		t.Errorf("expected ErrConcurrentChange, got %v", err)
+	}
+	if history, _ := store.ReadStream("atomic-1"); len(history) != 0 {
+		t.Errorf("failed batch was partially appended: %d events", len(history))
+	}
+}
+
+// receive collects n entries from the subscription or fails after the delivery timeout
+func (c *conformance) receive(t *testing.T, s es.Subscription, n int) []*es.Entry {
+	t.Helper()
+	received := make([]*es.Entry, 0, n)
+	timeout := time.After(c.timeout)
+	for len(received) < n {
+		select {
+		case batch, ok := <-s.Entries():
+			if !ok {
+				t.Fatalf("subscription closed after %d of %d entries: %v", len(received), n, s.Err())
+			}
+			received = append(received, batch...)
+		case <-timeout:
+			t.Fatalf("received %d of %d entries within %s", len(received), n, c.timeout)
+		}
+	}
+	return received
+}
+
+func mustAppend(t *testing.T, store es.EventStore, stream string, expectedVersion uint64, events ...*event.Event) {
+	t.Helper()
+	if err := store.AppendToStream(stream, expectedVersion, events...); err != nil {
+		t.Fatalf("append to %q: %s", stream, err)
+	}
+}
+
+func assertIDs(t *testing.T, history es.History, expected []string) {
+	t.Helper()
+	if len(history) != len(expected) {
+		t.Errorf("history has %d events, expected %d", len(history), len(expected))
+		return
+	}
+	for i, e := range history {
+		if e.ID() != expected[i] {
+			t.Errorf("event %d is %s, expected %s", i, e.ID(), expected[i])
+		}
+	}
+}
+
+func assertAscending(t *testing.T, entries []*es.Entry) {
+	t.Helper()
+	for i := 1; i < len(entries); i++ {
+		if entries[i].GlobalPos <= entries[i-1].GlobalPos {
+			t.Errorf("entry %d at global position %d after %d", i, entries[i].GlobalPos, entries[i-1].GlobalPos)
+		}
+	}
+}
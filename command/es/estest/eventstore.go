@@ -2,7 +2,7 @@ package estest
 
 import (
 	"fmt"
-	"log"
+	"sort"
 	"sync"
 	"time"
 
@@ -13,12 +13,32 @@ import (
 
 var _ es.EventStore = (*TestEventStore)(nil)
 var _ es.Transport = (*TestEventStore)(nil)
+var _ es.MultiEventStore = (*TestEventStore)(nil)
+var _ es.BitemporalReader = (*TestEventStore)(nil)
+var _ es.LogReader = (*TestEventStore)(nil)
+var _ es.HeadReader = (*TestEventStore)(nil)
+var _ es.StreamDeleter = (*TestEventStore)(nil)
+var _ es.Scavenger = (*TestEventStore)(nil)
 
 type TestEventStore struct {
 	sync.RWMutex
-	streams map[string][]*event.Event
+	streams map[string]*stream
 	log     []*es.Entry
-	entries chan []*es.Entry
+	nextPos uint64
+
+	broadcaster *es.Broadcaster
+}
+
+// stream keeps the stored entries of a stream. Entries with a version up to removed
+// are deleted or truncated and wait to be scavenged.
+type stream struct {
+	version uint64
+	removed uint64
+	entries []*es.Entry
+}
+
+func (s *stream) visible(entry *es.Entry) bool {
+	return entry.Version > s.removed
 }
 
 func WithTestEventStore(f func(es es.EventStore)) {
@@ -27,63 +47,229 @@ func WithTestEventStore(f func(es es.EventStore)) {
 }
 
 func NewTestEventStore() *TestEventStore {
-	return &TestEventStore{
-		streams: make(map[string][]*event.Event),
+	_es := &TestEventStore{
+		streams: make(map[string]*stream),
 		log:     make([]*es.Entry, 0),
-		entries: make(chan []*es.Entry),
 	}
+	_es.broadcaster = es.NewBroadcaster(_es)
+	return _es
 }
 
 func (_es *TestEventStore) ReadStream(name string) (es.History, error) {
 	_es.RLock()
 	defer _es.RUnlock()
-	return _es.streams[name], nil
+	return _es.history(name, func(_ *event.Event) bool { return true }), nil
 }
 
 func (_es *TestEventStore) ReadStreamAt(name string, at time.Time) (es.History, error) {
 	_es.RLock()
 	defer _es.RUnlock()
-	hist := make(es.History, 0)
-	for _, e := range _es.streams[name] {
-		if e.OccurredAt().Before(at) {
-			hist = append(hist, e)
-		}
-	}
-	return hist, nil
+	return _es.history(name, func(e *event.Event) bool { return e.OccurredAt().Before(at) }), nil
+}
+
+func (_es *TestEventStore) ReadStreamAsOf(name string, at, knownAt time.Time) (es.History, error) {
+	_es.RLock()
+	defer _es.RUnlock()
+	return _es.history(name, func(e *event.Event) bool {
+		return e.OccurredAt().Before(at) && !e.RecordedAt().After(knownAt)
+	}), nil
 }
 
 func (_es *TestEventStore) AppendToStream(name string, expectedVersion uint64, events ...*event.Event) error {
 	_es.Lock()
 	defer _es.Unlock()
-	history := _es.streams[name]
-	if uint64(len(history)) != expectedVersion {
-		return evently.Errorf(es.ErrConcurrentChange, "ErrConcurrentChange", name).
-			CausedBy(fmt.Errorf("concurrent change detected - expectedVersion: %d, actualVersion: %d", expectedVersion, len(history)))
+	if err := _es.checkVersion(name, expectedVersion); err != nil {
+		return err
 	}
-	_es.streams[name] = append(history, events...)
-	for _, e := range events {
-		_es.log = append(_es.log, es.NewEntry(uint64(len(_es.log)), e))
+	_es.append(name, events...)
+	return nil
+}
+
+// AppendMulti appends all events or none of them if any expected version doesn't match
+func (_es *TestEventStore) AppendMulti(events map[string]map[uint64][]*event.Event) error {
+	_es.Lock()
+	defer _es.Unlock()
+	streams := make([]string, 0, len(events))
+	for name := range events {
+		streams = append(streams, name)
+	}
+	sort.Strings(streams)
+	versions := make(map[string][]uint64, len(events))
+	for _, name := range streams {
+		var actual uint64
+		if s, ok := _es.streams[name]; ok {
+			actual = s.version
+		}
+		for expectedVersion := range events[name] {
+			versions[name] = append(versions[name], expectedVersion)
+		}
+		sort.Slice(versions[name], func(i, j int) bool { return versions[name][i] < versions[name][j] })
+		for _, expectedVersion := range versions[name] {
+			if expectedVersion != actual {
+				return concurrentChange(name, expectedVersion, actual)
+			}
+			actual += uint64(len(events[name][expectedVersion]))
+		}
+	}
+	for _, name := range streams {
+		for _, expectedVersion := range versions[name] {
+			_es.append(name, events[name][expectedVersion]...)
+		}
+	}
+	return nil
+}
+
+func (_es *TestEventStore) checkVersion(name string, expectedVersion uint64) error {
+	var actual uint64
+	if s, ok := _es.streams[name]; ok {
+		actual = s.version
+	}
+	if actual != expectedVersion {
+		return concurrentChange(name, expectedVersion, actual)
 	}
 	return nil
 }
 
-func (_es *TestEventStore) Subscribe() <-chan []*es.Entry {
-	offset := len(_es.log)
-	_es.entries <- _es.log[offset:]
-	return _es.entries
+func concurrentChange(name string, expectedVersion, actualVersion uint64) error {
+	return evently.Errorf(es.ErrConcurrentChange, "ErrConcurrentChange", "%s", name).
+		CausedBy(fmt.Errorf("concurrent change detected - expectedVersion: %d, actualVersion: %d", expectedVersion, actualVersion))
 }
 
-func (_es *TestEventStore) SubscribeWithOffset(offset uint64) <-chan []*es.Entry {
+// append records and appends the events to the stream and the global log. Must be called locked.
+func (_es *TestEventStore) append(name string, events ...*event.Event) {
+	s, ok := _es.streams[name]
+	if !ok {
+		s = &stream{}
+		_es.streams[name] = s
+	}
+	now := time.Now()
+	appended := make([]*es.Entry, 0, len(events))
+	for _, e := range events {
+		if e.RecordedAt().IsZero() {
+			e = e.Record(now)
+		}
+		s.version++
+		entry := &es.Entry{GlobalPos: _es.nextPos, Stream: name, Version: s.version, Event: e}
+		s.entries = append(s.entries, entry)
+		_es.log = append(_es.log, entry)
+		_es.nextPos++
+		appended = append(appended, entry)
+	}
+	_es.broadcaster.Publish(appended...)
+}
+
+func (_es *TestEventStore) ReadLog(offset uint64, max int) ([]*es.Entry, error) {
 	_es.RLock()
 	defer _es.RUnlock()
-	log.Printf("len(log)=%d, offset=%d", len(_es.log), offset)
-	entries := _es.log[offset:]
-	log.Printf("_es.log[offset:]=%d", len(entries))
-	go func() {
-		if len(entries) > 0 {
-			_es.entries <- entries
+	entries := _es.readLog(offset)
+	if max > 0 && len(entries) > max {
+		entries = entries[:max]
+	}
+	return entries, nil
+}
+
+func (_es *TestEventStore) Head() (uint64, error) {
+	_es.RLock()
+	defer _es.RUnlock()
+	return _es.nextPos, nil
+}
+
+func (_es *TestEventStore) DeleteStream(name string) error {
+	_es.Lock()
+	defer _es.Unlock()
+	if s, ok := _es.streams[name]; ok {
+		s.removed = s.version
+	}
+	return nil
+}
+
+func (_es *TestEventStore) TruncateStream(name string, version uint64) error {
+	_es.Lock()
+	defer _es.Unlock()
+	s, ok := _es.streams[name]
+	if !ok {
+		return nil
+	}
+	if version > s.version {
+		version = s.version
+	}
+	if version > s.removed {
+		s.removed = version
+	}
+	return nil
+}
+
+// Scavenge removes deleted and truncated events and compacts the configured streams.
+// The global positions of the surviving entries are kept.
+func (_es *TestEventStore) Scavenge(opts ...es.ScavengeOption) (*es.ScavengeResult, error) {
+	_es.Lock()
+	defer _es.Unlock()
+	scavenge := es.NewScavenge(opts...)
+	result := &es.ScavengeResult{}
+	latest := make(map[string]map[string]uint64) // stream -> key -> globalPos of latest entry
+	for _, entry := range _es.log {
+		if key, ok := scavenge.CompactionKey(entry); ok {
+			if latest[entry.Stream] == nil {
+				latest[entry.Stream] = make(map[string]uint64)
+			}
+			latest[entry.Stream][key] = entry.GlobalPos
 		}
-	}()
-	log.Printf("len(entries)=%d", len(_es.entries))
-	return _es.entries
+	}
+	survivors := make([]*es.Entry, 0, len(_es.log))
+	for _, s := range _es.streams {
+		s.entries = s.entries[:0]
+	}
+	for _, entry := range _es.log {
+		s := _es.streams[entry.Stream]
+		if !s.visible(entry) {
+			result.Removed++
+			continue
+		}
+		if key, ok := scavenge.CompactionKey(entry); ok && latest[entry.Stream][key] != entry.GlobalPos {
+			result.Compacted++
+			continue
+		}
+		survivors = append(survivors, entry)
+		s.entries = append(s.entries, entry)
+	}
+	_es.log = survivors
+	return result, nil
+}
+
+// Subscribe listens for new entries appended from now on
+func (_es *TestEventStore) Subscribe() es.Subscription {
+	head, _ := _es.Head()
+	return _es.broadcaster.Subscribe(head)
+}
+
+// SubscribeWithOffset fetches remaining entries based on given offset and listens for
+// new entries. Every subscription gets its own channel and offset.
+func (_es *TestEventStore) SubscribeWithOffset(offset uint64) es.Subscription {
+	return _es.broadcaster.Subscribe(offset)
+}
+
+// readLog returns all visible entries starting at the given global position
+func (_es *TestEventStore) readLog(offset uint64) []*es.Entry {
+	i := sort.Search(len(_es.log), func(i int) bool { return _es.log[i].GlobalPos >= offset })
+	entries := make([]*es.Entry, 0, len(_es.log)-i)
+	for _, entry := range _es.log[i:] {
+		if _es.streams[entry.Stream].visible(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (_es *TestEventStore) history(name string, filter func(e *event.Event) bool) es.History {
+	hist := make(es.History, 0)
+	s, ok := _es.streams[name]
+	if !ok {
+		return hist
+	}
+	for _, entry := range s.entries {
+		if s.visible(entry) && filter(entry.Event) {
+			hist = append(hist, entry.Event)
+		}
+	}
+	return hist
 }
@@ -2,6 +2,7 @@ package estest_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/openyard/evently/command/es"
 	"github.com/openyard/evently/command/es/estest"
@@ -44,3 +45,73 @@ func TestTestEventStore_AppentToStream(t *testing.T) {
 		}
 	})
 }
+
+func TestTestEventStore_Scavenge(t *testing.T) {
+	_es := estest.NewTestEventStore()
+	_ = _es.AppendToStream("customer-1", 0, event.NewDomainEvent("created", "1"), event.NewDomainEvent("renamed", "1"))
+	_ = _es.AppendToStream("customer-2", 0, event.NewDomainEvent("created", "2"))
+	_ = _es.AppendToStream("integration", 0,
+		event.NewIntegrationEvent("customer-changed", "1"),
+		event.NewIntegrationEvent("customer-changed", "2"),
+		event.NewIntegrationEvent("customer-changed", "1"))
+	if err := _es.TruncateStream("customer-1", 1); err != nil {
+		t.Errorf("failed to truncate stream: %s", err)
+		return
+	}
+	if err := _es.DeleteStream("customer-2"); err != nil {
+		t.Errorf("failed to delete stream: %s", err)
+		return
+	}
+
+	result, err := _es.Scavenge(es.WithCompaction(es.ByAggregateID, "integration"))
+	if err != nil {
+		t.Errorf("failed to scavenge: %s", err)
+		return
+	}
+	if result.Removed != 2 || result.Compacted != 1 {
+		t.Errorf("unexpected scavenge result: %+v", result)
+	}
+	history, _ := _es.ReadStream("customer-1")
+	if len(history) != 1 || history[0].Name() != "renamed" {
+		t.Errorf("unexpected history after scavenge: %+v", history)
+	}
+	if err := _es.AppendToStream("customer-2", 1, event.NewDomainEvent("created", "2")); err != nil {
+		t.Errorf("deleted stream lost its version: %s", err)
+	}
+
+	s := _es.SubscribeWithOffset(1)
+	defer s.Close()
+	entries := <-s.Entries()
+	expected := []uint64{1, 4, 5, 6}
+	if len(entries) != len(expected) {
+		t.Errorf("unexpected entries after scavenge: %d expected %d", len(entries), len(expected))
+		return
+	}
+	for i, entry := range entries {
+		if entry.GlobalPos != expected[i] {
+			t.Errorf("unexpected global position: %d expected %d", entry.GlobalPos, expected[i])
+		}
+	}
+}
+
+func TestTestEventStore_ReadStreamAsOf(t *testing.T) {
+	_es := estest.NewTestEventStore()
+	lastMonth := time.Now().AddDate(0, -1, 0)
+	_ = _es.AppendToStream("customer-1", 0, event.NewEventAt("created", "1", lastMonth))
+	knownBefore := time.Now()
+	time.Sleep(time.Millisecond)
+	_ = _es.AppendToStream("customer-1", 1, event.NewEventAt("corrected", "1", lastMonth.Add(time.Hour)))
+
+	history, _ := _es.ReadStreamAsOf("customer-1", time.Now(), knownBefore)
+	if len(history) != 1 || history[0].RecordedAt().IsZero() {
+		t.Errorf("unexpected history as known before the correction: %+v", history)
+	}
+	history, _ = _es.ReadStreamAsOf("customer-1", time.Now(), time.Now())
+	if len(history) != 2 {
+		t.Errorf("unexpected history as known now: %d events expected 2", len(history))
+	}
+}
+
+func TestTestEventStore_Conformance(t *testing.T) {
+	estest.TestConformance(t, func() es.EventStore { return estest.NewTestEventStore() })
+}
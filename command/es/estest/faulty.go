@@ -0,0 +1,303 @@
+package estest
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/event"
+)
+
+var _ es.EventStore = (*FaultyEventStore)(nil)
+var _ es.Transport = (*FaultyEventStore)(nil)
+
+// Op is an operation of an es.EventStore or es.Transport faults can be injected into
+type Op string
+
+const (
+	OpReadStream     Op = "ReadStream"
+	OpReadStreamAt   Op = "ReadStreamAt"
+	OpAppendToStream Op = "AppendToStream"
+	// OpDeliver is the delivery of entries to a subscriber
+	OpDeliver Op = "Deliver"
+)
+
+// Fault is the kind of fault a FaultRule injects
+type Fault string
+
+const (
+	FaultLatency   Fault = "Latency"
+	FaultError     Fault = "Error"
+	FaultConflict  Fault = "Conflict"
+	FaultDrop      Fault = "Drop"
+	FaultDuplicate Fault = "Duplicate"
+	FaultReorder   Fault = "Reorder"
+)
+
+// FaultRule injects a fault into an operation with a given probability
+type FaultRule struct {
+	op          Op
+	fault       Fault
+	stream      string
+	probability float64
+	latency     time.Duration
+	err         error
+}
+
+// ForStream restricts the rule to the given stream
+func (r FaultRule) ForStream(stream string) FaultRule {
+	r.stream = stream
+	return r
+}
+
+func (r FaultRule) matches(op Op, stream string) bool {
+	return r.op == op && (r.stream == "" || r.stream == stream)
+}
+
+// delivery reports whether the rule changes the delivered entries
+func (r FaultRule) delivery() bool {
+	return r.fault == FaultDrop || r.fault == FaultDuplicate || r.fault == FaultReorder
+}
+
+// InjectLatency delays the operation by d
+func InjectLatency(op Op, d time.Duration, probability float64) FaultRule {
+	return FaultRule{op: op, fault: FaultLatency, probability: probability, latency: d}
+}
+
+// InjectError fails the operation with err. For OpDeliver the subscription ends with err.
+func InjectError(op Op, err error, probability float64) FaultRule {
+	return FaultRule{op: op, fault: FaultError, probability: probability, err: err}
+}
+
+// InjectConflict fails AppendToStream with es.ErrConcurrentChange
+func InjectConflict(probability float64) FaultRule {
+	return FaultRule{op: OpAppendToStream, fault: FaultConflict, probability: probability}
+}
+
+// DropDeliveries doesn't deliver an entry to the subscriber
+func DropDeliveries(probability float64) FaultRule {
+	return FaultRule{op: OpDeliver, fault: FaultDrop, probability: probability}
+}
+
+// DuplicateDeliveries delivers an entry twice to the subscriber
+func DuplicateDeliveries(probability float64) FaultRule {
+	return FaultRule{op: OpDeliver, fault: FaultDuplicate, probability: probability}
+}
+
+// ReorderDeliveries shuffles the entries of a delivered batch
+func ReorderDeliveries(probability float64) FaultRule {
+	return FaultRule{op: OpDeliver, fault: FaultReorder, probability: probability}
+}
+
+// FaultyEventStore wraps an es.EventStore and es.Transport and injects faults driven by
+// FaultRules. All decisions are drawn from a random source with the given seed, so a
+// sequence of operations always sees the same faults.
+type FaultyEventStore struct {
+	sync.Mutex
+	store    es.EventStore
+	rand     *rand.Rand
+	rules    []FaultRule
+	injected map[Fault]int
+}
+
+// NewFaultyEventStore wraps the given store which must also implement es.Transport
+// to be subscribed to
+func NewFaultyEventStore(store es.EventStore, seed int64, rules ...FaultRule) *FaultyEventStore {
+	return &FaultyEventStore{
+		store:    store,
+		rand:     rand.New(rand.NewSource(seed)),
+		rules:    rules,
+		injected: make(map[Fault]int),
+	}
+}
+
+// Injected returns how often the given fault was injected
+func (f *FaultyEventStore) Injected(fault Fault) int {
+	f.Lock()
+	defer f.Unlock()
+	return f.injected[fault]
+}
+
+func (f *FaultyEventStore) ReadStream(name string) (es.History, error) {
+	if err := f.inject(OpReadStream, name); err != nil {
+		return nil, err
+	}
+	return f.store.ReadStream(name)
+}
+
+func (f *FaultyEventStore) ReadStreamAt(name string, at time.Time) (es.History, error) {
+	if err := f.inject(OpReadStreamAt, name); err != nil {
+		return nil, err
+	}
+	return f.store.ReadStreamAt(name, at)
+}
+
+func (f *FaultyEventStore) AppendToStream(name string, expectedVersion uint64, events ...*event.Event) error {
+	if err := f.inject(OpAppendToStream, name); err != nil {
+		return err
+	}
+	return f.store.AppendToStream(name, expectedVersion, events...)
+}
+
+func (f *FaultyEventStore) Subscribe() es.Subscription {
+	return f.deliver(f.store.(es.Transport).Subscribe())
+}
+
+func (f *FaultyEventStore) SubscribeWithOffset(offset uint64) es.Subscription {
+	return f.deliver(f.store.(es.Transport).SubscribeWithOffset(offset))
+}
+
+// inject draws the faults for the operation, sleeps on latency and returns the first
+// injected error
+func (f *FaultyEventStore) inject(op Op, stream string) error {
+	var latency time.Duration
+	var err error
+	f.sync(func() {
+		for _, rule := range f.rules {
+			if !rule.matches(op, stream) || rule.delivery() || !f.draw(rule) {
+				continue
+			}
+			switch rule.fault {
+			case FaultLatency:
+				latency += rule.latency
+			case FaultError:
+				if err == nil {
+					err = rule.err
+				}
+			case FaultConflict:
+				if err == nil {
+					err = evently.Errorf(es.ErrConcurrentChange, "ErrConcurrentChange", "%s", stream).
+						CausedBy(fmt.Errorf("injected concurrent change"))
+				}
+			}
+		}
+	})
+	time.Sleep(latency)
+	return err
+}
+
+// deliver forwards the batches of the given subscription and injects delivery faults
+func (f *FaultyEventStore) deliver(in es.Subscription) es.Subscription {
+	s := &faultySubscription{Subscription: in, out: make(chan []*es.Entry), done: make(chan struct{})}
+	go func() {
+		defer close(s.out)
+		defer in.Close()
+		for entries := range in.Entries() {
+			if err := f.inject(OpDeliver, ""); err != nil {
+				s.err.Store(err)
+				return
+			}
+			if batch := f.faultyBatch(entries); len(batch) > 0 {
+				if s.closed() {
+					return
+				}
+				select {
+				case s.out <- batch:
+				case <-s.done:
+					return
+				}
+			}
+		}
+	}()
+	return s
+}
+
+// faultySubscription delivers the faulty batches of the wrapped subscription
+type faultySubscription struct {
+	es.Subscription
+	out       chan []*es.Entry
+	done      chan struct{}
+	closeOnce sync.Once
+	err       atomic.Value
+}
+
+func (s *faultySubscription) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.Subscription.Close()
+	})
+}
+
+// closed reports whether the subscription was closed
+func (s *faultySubscription) closed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *faultySubscription) Entries() <-chan []*es.Entry {
+	return s.out
+}
+
+func (s *faultySubscription) Err() error {
+	if err, ok := s.err.Load().(error); ok {
+		return err
+	}
+	return s.Subscription.Err()
+}
+
+func (f *FaultyEventStore) faultyBatch(entries []*es.Entry) []*es.Entry {
+	batch := make([]*es.Entry, 0, len(entries))
+	f.sync(func() {
+		for _, entry := range entries {
+			dropped, duplicated := false, false
+			for _, rule := range f.rules {
+				if !rule.matches(OpDeliver, entry.Stream) {
+					continue
+				}
+				switch rule.fault {
+				case FaultDrop:
+					dropped = dropped || f.draw(rule)
+				case FaultDuplicate:
+					duplicated = duplicated || f.draw(rule)
+				}
+			}
+			if dropped {
+				continue
+			}
+			batch = append(batch, entry)
+			if duplicated {
+				batch = append(batch, entry)
+			}
+		}
+		for _, rule := range f.rules {
+			if rule.op != OpDeliver || rule.fault != FaultReorder {
+				continue
+			}
+			var matching []int // positions of the entries the rule applies to
+			for i, entry := range batch {
+				if rule.matches(OpDeliver, entry.Stream) {
+					matching = append(matching, i)
+				}
+			}
+			if len(matching) > 1 && f.draw(rule) {
+				f.rand.Shuffle(len(matching), func(i, j int) {
+					batch[matching[i]], batch[matching[j]] = batch[matching[j]], batch[matching[i]]
+				})
+			}
+		}
+	})
+	return batch
+}
+
+// draw decides whether the rule fires and counts the injected fault. Must be called synced.
+func (f *FaultyEventStore) draw(rule FaultRule) bool {
+	if f.rand.Float64() >= rule.probability {
+		return false
+	}
+	f.injected[rule.fault]++
+	return true
+}
+
+func (f *FaultyEventStore) sync(fn func()) {
+	f.Lock()
+	defer f.Unlock()
+	fn()
+}
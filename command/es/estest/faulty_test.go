@@ -0,0 +1,96 @@
+package estest_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/command/es/estest"
+	"github.com/openyard/evently/event"
+)
+
+func TestFaultyEventStore_AppendToStream(t *testing.T) {
+	errUnavailable := errors.New("unavailable")
+	outcomes := func() []error {
+		faulty := estest.NewFaultyEventStore(estest.NewTestEventStore(), 42,
+			estest.InjectConflict(0.3),
+			estest.InjectError(estest.OpAppendToStream, errUnavailable, 0.3).ForStream("customer-1"))
+		result := make([]error, 0, 20)
+		for i := 0; i < 20; i++ {
+			result = append(result, faulty.AppendToStream("customer-1", 0, event.NewDomainEvent("created", "1")))
+		}
+		return result
+	}
+
+	first, second := outcomes(), outcomes()
+	var conflicts int
+	for i := range first {
+		if (first[i] == nil) != (second[i] == nil) {
+			t.Errorf("call %d not reproducible: %v != %v", i, first[i], second[i])
+		}
+		var e *evently.Error
+		if errors.As(first[i], &e) && e.Code == es.ErrConcurrentChange {
+			conflicts++
+		}
+	}
+	if conflicts == 0 {
+		t.Error("no conflicts injected")
+	}
+}
+
+func TestFaultyEventStore_Subscribe(t *testing.T) {
+	store := estest.NewTestEventStore()
+	for i := 0; i < 10; i++ {
+		_ = store.AppendToStream("customer-1", uint64(i), event.NewDomainEvent("changed", "1"))
+	}
+	faulty := estest.NewFaultyEventStore(store, 7,
+		estest.DropDeliveries(0.2),
+		estest.DuplicateDeliveries(0.2),
+		estest.InjectLatency(estest.OpDeliver, time.Millisecond, 1))
+
+	s := faulty.SubscribeWithOffset(0)
+	defer s.Close()
+	entries := <-s.Entries()
+	expected := 10 - faulty.Injected(estest.FaultDrop) + faulty.Injected(estest.FaultDuplicate)
+	if len(entries) != expected {
+		t.Errorf("unexpected number of delivered entries: %d expected %d", len(entries), expected)
+	}
+	if faulty.Injected(estest.FaultDrop) == 0 || faulty.Injected(estest.FaultDuplicate) == 0 {
+		t.Errorf("faults not injected: drop=%d duplicate=%d",
+			faulty.Injected(estest.FaultDrop), faulty.Injected(estest.FaultDuplicate))
+	}
+}
+
+func TestFaultyEventStore_Subscribe_reorderStream(t *testing.T) {
+	store := estest.NewTestEventStore()
+	for i := 0; i < 10; i++ {
+		_ = store.AppendToStream("customer-1", uint64(i), event.NewDomainEvent("changed", "1"))
+		_ = store.AppendToStream("customer-2", uint64(i), event.NewDomainEvent("changed", "2"))
+	}
+	faulty := estest.NewFaultyEventStore(store, 7, estest.ReorderDeliveries(1).ForStream("customer-1"))
+
+	s := faulty.SubscribeWithOffset(0)
+	defer s.Close()
+	entries := <-s.Entries()
+	reordered := false
+	for i, entry := range entries {
+		if entry.Stream != "customer-2" {
+			reordered = reordered || entry.GlobalPos != uint64(i)
+			continue
+		}
+		if entry.GlobalPos != uint64(i) {
+			t.Errorf("entry of customer-2 moved: global position %d at %d", entry.GlobalPos, i)
+		}
+	}
+	if !reordered || faulty.Injected(estest.FaultReorder) == 0 {
+		t.Error("entries of customer-1 not reordered")
+	}
+}
+
+func TestFaultyEventStore_Conformance(t *testing.T) {
+	estest.TestConformance(t, func() es.EventStore {
+		return estest.NewFaultyEventStore(estest.NewTestEventStore(), 42)
+	})
+}
@@ -12,12 +12,13 @@ import (
 
 type T struct {
 	*testing.T
-	dm *command.DomainModel
+	dm   *command.DomainModel
+	when *command.Command
 }
 
 // WithDomainModel returns an initialized *estest.T with given domain-model
 func WithDomainModel(t *testing.T, dm *command.DomainModel) *T {
-	return &T{t, dm}
+	return &T{T: t, dm: dm}
 }
 
 // Given applies the given domain events to the model and executes the given func
@@ -27,10 +28,16 @@ func (t *T) Given(events ...*event.Event) *T {
 }
 
 func (t *T) When(c *command.Command) ([]*event.Event, error) {
+	t.when = c
 	return t.dm.Execute(c)
 }
 
+// Then reports whether the command of When caused the expected events. The expected
+// events record the command as causation like the actual ones.
 func (t *T) Then(expected ...*event.Event) bool {
+	if t.when != nil {
+		expected = command.Propagate(t.when, expected...)
+	}
 	var actual, given string
 	for _, c := range t.dm.Changes() {
 		marshalJSON, _ := json.MarshalIndent(c, "", "  ")
@@ -8,6 +8,8 @@ import (
 
 type Entry struct {
 	GlobalPos uint64
+	Stream    string
+	Version   uint64
 	Event     *event.Event
 }
 
@@ -25,18 +27,71 @@ type History []*event.Event
 type EventStore interface {
 	// ReadStream loads the stream and returns all its events
 	ReadStream(stream string) (History, error)
-	// ReadStreamAt loads the stream at a certain point in time and returns all its events up to this point
+	// ReadStreamAt loads the stream at a certain point in time and returns all its events
+	// occurred before this point
 	ReadStreamAt(stream string, at time.Time) (History, error)
-	// AppendToStream adds the events to the stream
+	// AppendToStream adds the events to the stream. Events not recorded yet are
+	// recorded at the time of the append (see event.Event RecordedAt).
 	AppendToStream(stream string, expectedVersion uint64, events ...*event.Event) error
 }
 
 // Transport interface provides methods to receive new events
 type Transport interface {
 	// Subscribe starts to listen for new events
-	Subscribe() <-chan []*Entry
+	Subscribe() Subscription
 	// SubscribeWithOffset fetches remaining events based on given offset and listen for new events
-	SubscribeWithOffset(offset uint64) <-chan []*Entry
+	SubscribeWithOffset(offset uint64) Subscription
+}
+
+// Phase of a Subscription
+type Phase int
+
+const (
+	// CatchUp is the phase of reading entries appended before
+	CatchUp Phase = iota
+	// Live is the phase of receiving entries as they are appended
+	Live
+)
+
+func (p Phase) String() string {
+	if p == Live {
+		return "Live"
+	}
+	return "CatchUp"
+}
+
+// Subscription delivers the entries of a Transport until it's closed or fails
+type Subscription interface {
+	// Entries returns the channel of delivered entries, which is closed when the subscription ends
+	Entries() <-chan []*Entry
+	// Err returns the error which ended the subscription or nil
+	Err() error
+	// Phase returns whether the subscription is catching up or live
+	Phase() Phase
+	// Live returns a channel which is closed when the subscription caught up the first time
+	Live() <-chan struct{}
+	// Close ends the subscription and closes the channel of entries
+	Close()
+}
+
+// BitemporalReader reads streams by valid time (event.Event OccurredAt) and
+// transaction time (event.Event RecordedAt)
+type BitemporalReader interface {
+	// ReadStreamAsOf loads the stream with all events occurred before the given point in time
+	// and recorded up to knownAt
+	ReadStreamAsOf(stream string, at, knownAt time.Time) (History, error)
+}
+
+// LogReader reads the global log of all streams in order of their global position
+type LogReader interface {
+	// ReadLog returns up to max entries of the global log starting at the given global position
+	ReadLog(offset uint64, max int) ([]*Entry, error)
+}
+
+// HeadReader reads the head of the global log
+type HeadReader interface {
+	// Head returns the global position the next appended entry will get
+	Head() (uint64, error)
 }
 
 // MultiEventStore appends events for multiple streams at once
@@ -0,0 +1,4 @@
+// Package jsonl exports the global log of an event store into JSON Lines and imports
+// it into any es.EventStore. Each line holds one es.Entry with its global position,
+// stream, version and the event including its metadata.
+package jsonl
@@ -0,0 +1,10 @@
+package jsonl
+
+// error codes
+const (
+	ErrDecode = iota + 9201
+	ErrEncode
+	ErrOrder
+	ErrVerification
+	ErrNotEmpty
+)
@@ -0,0 +1,265 @@
+package jsonl
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/pkg/timeutil"
+)
+
+const (
+	maxLineSize = 16 * 1024 * 1024
+	pageSize    = 1000
+)
+
+// Encoder writes entries as JSON Lines
+type Encoder struct {
+	enc *json.Encoder
+}
+
+// NewEncoder returns an Encoder writing to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{enc: json.NewEncoder(w)}
+}
+
+// Encode writes the given entry as a single line
+func (e *Encoder) Encode(entry *es.Entry) error {
+	if err := e.enc.Encode(entry); err != nil {
+		return evently.Errorf(ErrEncode, "ErrEncode", "entry at global position %d", entry.GlobalPos).CausedBy(err)
+	}
+	return nil
+}
+
+// Decoder reads entries from JSON Lines
+type Decoder struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewDecoder returns a Decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return &Decoder{scanner: scanner}
+}
+
+// Decode reads the next entry and returns io.EOF if there are no more entries
+func (d *Decoder) Decode() (*es.Entry, error) {
+	for d.scanner.Scan() {
+		d.line++
+		line := d.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		entry := &es.Entry{}
+		if err := json.Unmarshal(line, entry); err != nil {
+			return nil, evently.Errorf(ErrDecode, "ErrDecode", "line %d", d.line).CausedBy(err)
+		}
+		if entry.Event == nil {
+			return nil, evently.Errorf(ErrDecode, "ErrDecode", "line %d: missing event", d.line)
+		}
+		return entry, nil
+	}
+	if err := d.scanner.Err(); err != nil {
+		return nil, evently.Errorf(ErrDecode, "ErrDecode", "line %d", d.line).CausedBy(err)
+	}
+	return nil, io.EOF
+}
+
+// Option selects the entries to export or import
+type Option func(f *filter)
+
+type filter struct {
+	streams   map[string]bool
+	timeRange *timeutil.TimeRange
+}
+
+// WithStreams selects only entries of the given streams
+func WithStreams(streams ...string) Option {
+	return func(f *filter) {
+		if f.streams == nil {
+			f.streams = make(map[string]bool, len(streams))
+		}
+		for _, s := range streams {
+			f.streams[s] = true
+		}
+	}
+}
+
+// WithTimeRange selects only entries whose event occurred within the given time range
+func WithTimeRange(tr *timeutil.TimeRange) Option {
+	return func(f *filter) {
+		f.timeRange = tr
+	}
+}
+
+func newFilter(opts ...Option) *filter {
+	f := &filter{}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+func (f *filter) match(entry *es.Entry) bool {
+	if f.streams != nil && !f.streams[entry.Stream] {
+		return false
+	}
+	return f.timeRange == nil || f.timeRange.Includes(entry.Event.OccurredAt())
+}
+
+// Result reports the entries which were exported, imported or verified
+type Result struct {
+	// Entries is the total number of entries
+	Entries int
+	// Streams holds the number of entries per stream
+	Streams map[string]int
+}
+
+func newResult() *Result {
+	return &Result{Streams: make(map[string]int)}
+}
+
+func (r *Result) add(entry *es.Entry) {
+	r.Entries++
+	r.Streams[entry.Stream]++
+}
+
+// order verifies that global positions and stream versions are strictly increasing
+type order struct {
+	started   bool
+	globalPos uint64
+	versions  map[string]uint64
+}
+
+func (o *order) verify(entry *es.Entry) error {
+	if o.started && entry.GlobalPos <= o.globalPos {
+		return evently.Errorf(ErrOrder, "ErrOrder", "global position %d after %d", entry.GlobalPos, o.globalPos)
+	}
+	if o.versions == nil {
+		o.versions = make(map[string]uint64)
+	}
+	if v, ok := o.versions[entry.Stream]; ok && entry.Version <= v {
+		return evently.Errorf(ErrOrder, "ErrOrder", "stream %q: version %d after %d", entry.Stream, entry.Version, v)
+	}
+	o.started = true
+	o.globalPos = entry.GlobalPos
+	o.versions[entry.Stream] = entry.Version
+	return nil
+}
+
+// Export writes the global log of the given store as JSON Lines to w
+func Export(w io.Writer, src es.LogReader, opts ...Option) (*Result, error) {
+	f := newFilter(opts...)
+	enc := NewEncoder(w)
+	result := newResult()
+	var offset uint64
+	for {
+		entries, err := src.ReadLog(offset, pageSize)
+		if err != nil {
+			return result, err
+		}
+		if len(entries) == 0 {
+			return result, nil
+		}
+		for _, entry := range entries {
+			if !f.match(entry) {
+				continue
+			}
+			if err := enc.Encode(entry); err != nil {
+				return result, err
+			}
+			result.add(entry)
+		}
+		offset = entries[len(entries)-1].GlobalPos + 1
+	}
+}
+
+// Import replays the JSON Lines from r into the given store. Every imported stream must
+// be empty in the store, otherwise the import fails with ErrNotEmpty before appending
+// to it. After the import the number of events per stream is verified against the store.
+func Import(r io.Reader, dst es.EventStore, opts ...Option) (*Result, error) {
+	f := newFilter(opts...)
+	dec := NewDecoder(r)
+	result := newResult()
+	versions := make(map[string]uint64) // expected version per stream in dst
+	o := &order{}
+	for {
+		entry, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+		if err := o.verify(entry); err != nil {
+			return result, err
+		}
+		if !f.match(entry) {
+			continue
+		}
+		version, ok := versions[entry.Stream]
+		if !ok {
+			history, err := dst.ReadStream(entry.Stream)
+			if err != nil {
+				return result, err
+			}
+			if len(history) > 0 {
+				return result, evently.Errorf(ErrNotEmpty, "ErrNotEmpty", "stream %q has %d events", entry.Stream, len(history))
+			}
+		}
+		if err := dst.AppendToStream(entry.Stream, version, entry.Event); err != nil {
+			return result, err
+		}
+		versions[entry.Stream] = version + 1
+		result.add(entry)
+	}
+	for stream, version := range versions {
+		history, err := dst.ReadStream(stream)
+		if err != nil {
+			return result, err
+		}
+		if uint64(len(history)) != version {
+			return result, evently.Errorf(ErrVerification, "ErrVerification", "stream %q", stream).
+				CausedBy(fmt.Errorf("expected %d events, but found %d", version, len(history)))
+		}
+	}
+	return result, nil
+}
+
+// Copy verifies the JSON Lines from r and writes the selected entries to w
+func Copy(w io.Writer, r io.Reader, opts ...Option) (*Result, error) {
+	f := newFilter(opts...)
+	dec := NewDecoder(r)
+	enc := NewEncoder(w)
+	result := newResult()
+	o := &order{}
+	for {
+		entry, err := dec.Decode()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+		if err := o.verify(entry); err != nil {
+			return result, err
+		}
+		if !f.match(entry) {
+			continue
+		}
+		if err := enc.Encode(entry); err != nil {
+			return result, err
+		}
+		result.add(entry)
+	}
+}
+
+// Verify checks the ordering of the JSON Lines from r and counts the selected entries
+func Verify(r io.Reader, opts ...Option) (*Result, error) {
+	return Copy(io.Discard, r, opts...)
+}
@@ -0,0 +1,83 @@
+package jsonl_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es/estest"
+	"github.com/openyard/evently/command/es/jsonl"
+	"github.com/openyard/evently/event"
+)
+
+func TestExportImport(t *testing.T) {
+	src := estest.NewTestEventStore()
+	_ = src.AppendToStream("customer-1", 0,
+		event.NewDomainEvent("created", "1", event.WithPayload([]byte(`{"name":"John"}`)),
+			event.WithMetadata(map[string]string{"tenant": "acme"})),
+		event.NewDomainEvent("renamed", "1"))
+	_ = src.AppendToStream("customer-2", 0, event.NewDomainEvent("created", "2"))
+
+	var buf bytes.Buffer
+	exported, err := jsonl.Export(&buf, src)
+	if err != nil {
+		t.Errorf("export failed: %s", err)
+		return
+	}
+	if exported.Entries != 3 || strings.Count(buf.String(), "\n") != 3 {
+		t.Errorf("unexpected export: %d entries\n%s", exported.Entries, buf.String())
+		return
+	}
+
+	dst := estest.NewTestEventStore()
+	imported, err := jsonl.Import(&buf, dst, jsonl.WithStreams("customer-1"))
+	if err != nil {
+		t.Errorf("import failed: %s", err)
+		return
+	}
+	if imported.Entries != 2 || imported.Streams["customer-1"] != 2 {
+		t.Errorf("unexpected import: %+v", imported)
+	}
+	history, _ := dst.ReadStream("customer-1")
+	if len(history) != 2 {
+		t.Errorf("unexpected history length: %d expected 2", len(history))
+		return
+	}
+	if string(history[0].Payload()) != `{"name":"John"}` || history[0].Metadata()["tenant"] != "acme" {
+		t.Errorf("event not preserved: payload=%s metadata=%v", history[0].Payload(), history[0].Metadata())
+	}
+}
+
+func TestVerify_order(t *testing.T) {
+	src := estest.NewTestEventStore()
+	_ = src.AppendToStream("customer-1", 0, event.NewDomainEvent("created", "1"), event.NewDomainEvent("renamed", "1"))
+	var buf bytes.Buffer
+	_, _ = jsonl.Export(&buf, src)
+	lines := strings.SplitAfter(buf.String(), "\n")
+
+	_, err := jsonl.Verify(strings.NewReader(lines[1] + lines[0]))
+	var e *evently.Error
+	if !errors.As(err, &e) || e.Code != jsonl.ErrOrder {
+		t.Errorf("expected ErrOrder, got %v", err)
+	}
+}
+
+func TestImport_notEmpty(t *testing.T) {
+	src := estest.NewTestEventStore()
+	_ = src.AppendToStream("customer-1", 0, event.NewDomainEvent("created", "1"))
+	var buf bytes.Buffer
+	_, _ = jsonl.Export(&buf, src)
+
+	dst := estest.NewTestEventStore()
+	_ = dst.AppendToStream("customer-1", 0, event.NewDomainEvent("created", "1"))
+	_, err := jsonl.Import(&buf, dst)
+	var e *evently.Error
+	if !errors.As(err, &e) || e.Code != jsonl.ErrNotEmpty {
+		t.Errorf("expected ErrNotEmpty, got %v", err)
+	}
+	if history, _ := dst.ReadStream("customer-1"); len(history) != 1 {
+		t.Errorf("unexpected history length: %d expected 1", len(history))
+	}
+}
@@ -0,0 +1,7 @@
+package replication
+
+// error codes
+const (
+	ErrDiverged = iota + 9301
+	ErrNoVersion
+)
@@ -0,0 +1,162 @@
+// Package replication replicates the events of a source event store into a target event store
+package replication
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/query/consume"
+	"github.com/openyard/evently/query/subscription"
+)
+
+// Option configures a Replicator
+type Option func(r *Replicator)
+
+// Lag reports how far a Replicator is behind its source
+type Lag struct {
+	// Position is the global position of the source the replicator continues from
+	Position uint64
+	// Behind is the number of source positions not replicated yet. It is only known
+	// if the source implements es.HeadReader.
+	Behind uint64
+	// Delay is the time between the last replicated event was recorded in the source and
+	// its replication. It is only known if the source records its events.
+	Delay time.Duration
+}
+
+// Replicator subscribes to a source es.Transport and appends all entries into a target
+// es.EventStore with the same stream name, version and event ID. Its checkpoint is
+// stored after each batch, so a restarted Replicator resumes where it stopped.
+type Replicator struct {
+	sync.RWMutex
+	id          string
+	source      es.Transport
+	target      es.EventStore
+	checkpoints subscription.CheckpointStore
+	checkpoint  *subscription.Checkpoint
+	retry       time.Duration
+	delay       time.Duration
+
+	subscription *subscription.CatchUpSubscription
+}
+
+// NewReplicator returns a Replicator identified by the given id. The id names the
+// checkpoint of the replicator, by default kept in a subscription.MemoryCheckpointStore.
+// A stored checkpoint is loaded immediately.
+func NewReplicator(id string, source es.Transport, target es.EventStore, opts ...Option) *Replicator {
+	r := &Replicator{
+		id:          id,
+		source:      source,
+		target:      target,
+		checkpoints: subscription.NewMemoryCheckpointStore(),
+		retry:       time.Second,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.checkpoint = r.checkpoints.GetLatestCheckpoint(id)
+	if r.checkpoint == nil {
+		r.checkpoint = subscription.NewCheckpoint(id, 0, time.Time{})
+	}
+	return r
+}
+
+// WithCheckpointStore persists the checkpoint of the replicator in the given store
+func WithCheckpointStore(store subscription.CheckpointStore) Option {
+	return func(r *Replicator) {
+		r.checkpoints = store
+	}
+}
+
+// WithRetryInterval sets the time to wait before a failed entry is replicated again.
+// The default is 1s.
+func WithRetryInterval(d time.Duration) Option {
+	return func(r *Replicator) {
+		r.retry = d
+	}
+}
+
+// Start starts to replicate from the checkpoint of the replicator
+func (r *Replicator) Start() {
+	r.Lock()
+	defer r.Unlock()
+	r.subscription = subscription.NewCatchUpSubscription(r.source,
+		subscription.WithCheckpoint(r.checkpoint),
+		subscription.WithConsumer(consume.ConsumerFunc(r.replicate)),
+		subscription.WithRetryInterval(r.retry),
+		subscription.WithResubscribeOnError())
+	r.subscription.Listen()
+}
+
+// Stop stops to replicate
+func (r *Replicator) Stop() {
+	r.Lock()
+	defer r.Unlock()
+	if r.subscription != nil {
+		r.subscription.Stop()
+		r.subscription = nil
+	}
+}
+
+// Lag returns the current replication lag
+func (r *Replicator) Lag() Lag {
+	r.RLock()
+	defer r.RUnlock()
+	lag := Lag{Position: r.checkpoint.GlobalPosition(), Delay: r.delay}
+	if head, ok := r.source.(es.HeadReader); ok {
+		if pos, err := head.Head(); err == nil && pos > lag.Position {
+			lag.Behind = pos - lag.Position
+		}
+	}
+	return lag
+}
+
+// replicate appends the entries one by one and moves the checkpoint behind each
+// appended entry. A failing entry ends the subscription, which subscribes again from the
+// checkpoint after the retry interval, so replication resumes at the first entry not replicated.
+func (r *Replicator) replicate(_ *consume.Context, entries ...*es.Entry) error {
+	defer r.checkpoints.StoreCheckpoint(r.checkpoint)
+	for _, entry := range entries {
+		if entry.GlobalPos < r.checkpoint.GlobalPosition() {
+			continue // already replicated
+		}
+		if err := r.append(entry); err != nil {
+			return err
+		}
+		r.checkpoint.Update(entry.GlobalPos + 1)
+		if entry.Event.RecordedAt().IsZero() {
+			continue // the delay is unknown
+		}
+		r.Lock()
+		r.delay = time.Since(entry.Event.RecordedAt())
+		r.Unlock()
+	}
+	return nil
+}
+
+func (r *Replicator) append(entry *es.Entry) error {
+	if entry.Version == 0 { // versions start at 1, the source doesn't report them
+		return evently.Errorf(ErrNoVersion, "ErrNoVersion", "entry at global position %d of stream %q", entry.GlobalPos, entry.Stream)
+	}
+	err := r.target.AppendToStream(entry.Stream, entry.Version-1, entry.Event)
+	var e *evently.Error
+	if err == nil || !errors.As(err, &e) || e.Code != es.ErrConcurrentChange {
+		return err
+	}
+	// the entry might be replicated before the checkpoint was stored
+	history, rerr := r.target.ReadStream(entry.Stream)
+	if rerr != nil {
+		return rerr
+	}
+	for _, replicated := range history {
+		if replicated.ID() == entry.Event.ID() {
+			return nil
+		}
+	}
+	return evently.Errorf(ErrDiverged, "ErrDiverged", "stream %q diverged at version %d", entry.Stream, entry.Version).
+		CausedBy(fmt.Errorf("event %s not found in target: %w", entry.Event.ID(), err))
+}
@@ -0,0 +1,95 @@
+package replication_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openyard/evently/command/es/estest"
+	"github.com/openyard/evently/command/es/replication"
+	"github.com/openyard/evently/event"
+	"github.com/openyard/evently/query/subscription"
+)
+
+func TestReplicator_resume(t *testing.T) {
+	source := estest.NewTestEventStore()
+	target := estest.NewTestEventStore()
+	checkpoints := subscription.NewFileCheckpointStore(t.TempDir())
+	_ = source.AppendToStream("customer-1", 0, event.NewDomainEvent("created", "1"), event.NewDomainEvent("renamed", "1"))
+	_ = source.AppendToStream("customer-2", 0, event.NewDomainEvent("created", "2"))
+
+	r := replication.NewReplicator("standby", source, target, replication.WithCheckpointStore(checkpoints))
+	r.Start()
+	waitFor(t, func() bool { return r.Lag().Position == 3 })
+	r.Stop()
+	if lag := r.Lag(); lag.Behind != 0 {
+		t.Errorf("unexpected lag: %+v", lag)
+	}
+
+	_ = source.AppendToStream("customer-1", 2, event.NewDomainEvent("blocked", "1"))
+	r = replication.NewReplicator("standby", source, target, replication.WithCheckpointStore(checkpoints))
+	if lag := r.Lag(); lag.Behind != 1 {
+		t.Errorf("unexpected lag before start: %+v", lag)
+	}
+	r.Start()
+	defer r.Stop()
+	waitFor(t, func() bool { return r.Lag().Position == 4 })
+
+	for _, stream := range []string{"customer-1", "customer-2"} {
+		expected, _ := source.ReadStream(stream)
+		actual, _ := target.ReadStream(stream)
+		if len(actual) != len(expected) {
+			t.Errorf("stream %q: %d events replicated, expected %d", stream, len(actual), len(expected))
+			continue
+		}
+		for i := range expected {
+			if actual[i].ID() != expected[i].ID() {
+				t.Errorf("stream %q: event %s replicated as %s", stream, expected[i].ID(), actual[i].ID())
+			}
+		}
+	}
+}
+
+func TestReplicator_Lag(t *testing.T) {
+	source := estest.NewTestEventStore()
+	_ = source.AppendToStream("customer-1", 0, event.NewEventAt("created", "1", time.Now().AddDate(0, -1, 0)))
+
+	r := replication.NewReplicator("standby", source, estest.NewTestEventStore())
+	r.Start()
+	defer r.Stop()
+	waitFor(t, func() bool { return r.Lag().Position == 1 })
+	if lag := r.Lag(); lag.Delay > time.Second {
+		t.Errorf("expected delay since the event was recorded, got %s", lag.Delay)
+	}
+}
+
+func TestReplicator_retry(t *testing.T) {
+	source := estest.NewTestEventStore()
+	// the first append to stream "a" fails, all later appends succeed
+	target := estest.NewFaultyEventStore(estest.NewTestEventStore(), 1,
+		estest.InjectError(estest.OpAppendToStream, errors.New("unavailable"), 0.7).ForStream("a"))
+
+	r := replication.NewReplicator("standby", source, target, replication.WithRetryInterval(10*time.Millisecond))
+	r.Start()
+	defer r.Stop()
+	_ = source.AppendToStream("a", 0, event.NewDomainEvent("created", "a"))
+	waitFor(t, func() bool { return target.Injected(estest.FaultError) == 1 })
+	_ = source.AppendToStream("b", 0, event.NewDomainEvent("created", "b"))
+	waitFor(t, func() bool { return r.Lag().Position == 2 })
+
+	for _, stream := range []string{"a", "b"} {
+		if h, _ := target.ReadStream(stream); len(h) != 1 {
+			t.Errorf("stream %q: %d events replicated, expected 1", stream, len(h))
+		}
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,76 @@
+package es
+
+import "github.com/openyard/evently/event"
+
+// StreamDeleter removes streams or parts of streams logically. Removed events are no
+// longer returned by reads and subscriptions, but stay in the storage until a
+// Scavenger removes them physically.
+type StreamDeleter interface {
+	// DeleteStream removes all events of the stream. The version of the stream is kept,
+	// so new events must be appended with the latest version as expectedVersion.
+	DeleteStream(stream string) error
+	// TruncateStream removes all events of the stream up to and including the given version
+	TruncateStream(stream string, version uint64) error
+}
+
+// Scavenger physically removes deleted and truncated events from a durable store.
+// Surviving events keep their global position, so checkpoints of subscriptions stay valid.
+type Scavenger interface {
+	// Scavenge removes deleted, truncated and (optionally) compacted events from the storage
+	Scavenge(opts ...ScavengeOption) (*ScavengeResult, error)
+}
+
+// KeyFunc returns the compaction key of the given event
+type KeyFunc func(e *event.Event) string
+
+// ScavengeOption configures a Scavenge run
+type ScavengeOption func(s *Scavenge)
+
+// Scavenge holds the configuration of a scavenge run
+type Scavenge struct {
+	// Compactions maps a stream to the KeyFunc used to compact it
+	Compactions map[string]KeyFunc
+}
+
+// ScavengeResult reports the outcome of a scavenge run
+type ScavengeResult struct {
+	// Removed is the number of deleted or truncated events which were removed physically
+	Removed int
+	// Compacted is the number of integration events which were superseded by a newer one with the same key
+	Compacted int
+}
+
+// NewScavenge returns a Scavenge configured by the given options
+func NewScavenge(opts ...ScavengeOption) *Scavenge {
+	s := &Scavenge{Compactions: make(map[string]KeyFunc)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithCompaction compacts the given streams to the latest event per key. Only events
+// of kind event.IntegrationEvent are compacted, domain events are the history of an
+// aggregate and always kept.
+func WithCompaction(key KeyFunc, streams ...string) ScavengeOption {
+	return func(s *Scavenge) {
+		for _, stream := range streams {
+			s.Compactions[stream] = key
+		}
+	}
+}
+
+// ByAggregateID is a KeyFunc compacting events by their aggregateID
+func ByAggregateID(e *event.Event) string {
+	return e.AggregateID()
+}
+
+// CompactionKey returns the compaction key of the given entry and whether the entry is
+// subject to compaction at all
+func (s *Scavenge) CompactionKey(entry *Entry) (string, bool) {
+	key, ok := s.Compactions[entry.Stream]
+	if !ok || entry.Event.Kind() != event.IntegrationEvent {
+		return "", false
+	}
+	return key(entry.Event), true
+}
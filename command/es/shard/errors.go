@@ -0,0 +1,6 @@
+package shard
+
+// error codes
+const (
+	ErrRebalance = iota + 9401
+)
@@ -0,0 +1,82 @@
+package shard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/openyard/evently/command/es"
+)
+
+const (
+	shardBits = 8
+	posBits   = 64 - shardBits
+	posMask   = 1<<posBits - 1
+	// MaxShards is the maximum number of shards a Router can hold
+	MaxShards = 1 << shardBits
+)
+
+// EncodePosition returns the global position of an entry in the merged log of a Router.
+// The upper 8 bits hold the shard, the lower 56 bits the position in the log of the shard.
+// Positions only ascend within a shard, see Position to resume the merged log.
+func EncodePosition(shard int, pos uint64) uint64 {
+	return uint64(shard)<<posBits | pos&posMask
+}
+
+// DecodePosition returns the shard and the position in the log of the shard
+func DecodePosition(globalPos uint64) (int, uint64) {
+	return int(globalPos >> posBits), globalPos & posMask
+}
+
+// Position is the composite position in the merged log of a Router. It holds the
+// next position to read for every shard.
+type Position []uint64
+
+// ParsePosition parses a Position in the format of Position.String
+func ParsePosition(s string) (Position, error) {
+	if s == "" {
+		return Position{}, nil
+	}
+	parts := strings.Split(s, ".")
+	p := make(Position, len(parts))
+	for i, part := range parts {
+		pos, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid position %q: %w", s, err)
+		}
+		p[i] = pos
+	}
+	return p, nil
+}
+
+// Advance returns the position behind the given entries of the merged log
+func (p Position) Advance(entries ...*es.Entry) Position {
+	next := append(Position{}, p...)
+	for _, entry := range entries {
+		shard, pos := DecodePosition(entry.GlobalPos)
+		for len(next) <= shard {
+			next = append(next, 0)
+		}
+		if pos+1 > next[shard] {
+			next[shard] = pos + 1
+		}
+	}
+	return next
+}
+
+// Of returns the next position to read for the given shard
+func (p Position) Of(shard int) uint64 {
+	if shard < len(p) {
+		return p[shard]
+	}
+	return 0
+}
+
+// String formats the position as dot separated positions per shard, e.g. "12.0.7"
+func (p Position) String() string {
+	parts := make([]string, len(p))
+	for i, pos := range p {
+		parts[i] = strconv.FormatUint(pos, 10)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,46 @@
+package shard
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+// ring assigns streams to shards by consistent hashing. Every shard is placed on the
+// ring multiple times (virtual nodes) to spread the streams evenly.
+type ring struct {
+	hashes []uint64
+	owners map[uint64]int
+}
+
+func newRing(shards, vnodes int) *ring {
+	r := &ring{owners: make(map[uint64]int, shards*vnodes)}
+	for s := 0; s < shards; s++ {
+		for v := 0; v < vnodes; v++ {
+			h := hash(fmt.Sprintf("shard-%d#%d", s, v))
+			if _, taken := r.owners[h]; taken {
+				continue
+			}
+			r.owners[h] = s
+			r.hashes = append(r.hashes, h)
+		}
+	}
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+	return r
+}
+
+// shard returns the shard owning the given stream
+func (r *ring) shard(stream string) int {
+	h := hash(stream)
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.owners[r.hashes[i]]
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum64()
+}
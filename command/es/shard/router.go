@@ -0,0 +1,221 @@
+// Package shard distributes streams over several event stores
+package shard
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/event"
+)
+
+const defaultVirtualNodes = 64
+
+var _ es.EventStore = (*Router)(nil)
+
+type Option func(r *Router)
+
+// Router routes every stream to one of its shards by consistent hashing of the stream
+// name. The logs of all shards are merged into a single subscribable log, in which the
+// global position of an entry encodes its shard (see EncodePosition). Entries of a stream
+// keep their order, entries of different shards are merged in order of arrival.
+//
+// As the global positions of the merged log don't ascend across shards, the Router isn't
+// an es.Transport: a consumer resuming at a single offset would miss the entries of all
+// shards behind it. Resume the merged log with SubscribeFrom, or consume every shard with
+// its own subscription and checkpoint.
+type Router struct {
+	sync.RWMutex
+	shards []es.EventStore
+	ring   *ring
+	vnodes int
+	feeds  map[*subscription]struct{}
+}
+
+// NewRouter returns a Router over the given shards. To be subscribed to, all shards
+// must implement es.Transport.
+func NewRouter(shards []es.EventStore, opts ...Option) *Router {
+	r := &Router{
+		shards: shards,
+		vnodes: defaultVirtualNodes,
+		feeds:  make(map[*subscription]struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.ring = newRing(len(shards), r.vnodes)
+	return r
+}
+
+// WithVirtualNodes places every shard n times on the hash ring, default is 64
+func WithVirtualNodes(n int) Option {
+	return func(r *Router) {
+		r.vnodes = n
+	}
+}
+
+// Shard returns the index of the shard owning the given stream
+func (r *Router) Shard(stream string) int {
+	r.RLock()
+	defer r.RUnlock()
+	return r.ring.shard(stream)
+}
+
+func (r *Router) ReadStream(stream string) (es.History, error) {
+	r.RLock()
+	defer r.RUnlock()
+	return r.shards[r.ring.shard(stream)].ReadStream(stream)
+}
+
+func (r *Router) ReadStreamAt(stream string, at time.Time) (es.History, error) {
+	r.RLock()
+	defer r.RUnlock()
+	return r.shards[r.ring.shard(stream)].ReadStreamAt(stream, at)
+}
+
+func (r *Router) AppendToStream(stream string, expectedVersion uint64, events ...*event.Event) error {
+	r.RLock()
+	defer r.RUnlock()
+	return r.shards[r.ring.shard(stream)].AppendToStream(stream, expectedVersion, events...)
+}
+
+// Subscribe listens for new entries of all shards
+func (r *Router) Subscribe() es.Subscription {
+	r.Lock()
+	defer r.Unlock()
+	s := r.newSubscription()
+	for i, shard := range r.shards {
+		s.attach(i, shard.(es.Transport).Subscribe())
+	}
+	s.started()
+	return s
+}
+
+// SubscribeFrom fetches the remaining entries of all shards based on the given
+// position and listens for new entries
+func (r *Router) SubscribeFrom(p Position) es.Subscription {
+	r.Lock()
+	defer r.Unlock()
+	s := r.newSubscription()
+	for i, shard := range r.shards {
+		s.attach(i, shard.(es.Transport).SubscribeWithOffset(p.Of(i)))
+	}
+	s.started()
+	return s
+}
+
+// newSubscription returns a merged subscription known to the router. Must be called locked.
+func (r *Router) newSubscription() *subscription {
+	s := newSubscription(func(s *subscription) {
+		r.Lock()
+		defer r.Unlock()
+		delete(r.feeds, s)
+	})
+	r.feeds[s] = struct{}{}
+	return s
+}
+
+// Rebalance reports the streams moved to another shard
+type Rebalance struct {
+	Streams []string
+	Events  int
+}
+
+// AddShard adds the given store as new shard and moves all streams now owned by the new
+// shard. The existing shards must implement es.LogReader to find their streams, the new
+// shard must implement es.MultiEventStore to receive the moved streams all at once, so a
+// failed AddShard leaves the router and the new store unchanged. Moved streams keep their
+// version, thus streams truncated before can't be moved. They are deleted from their
+// former shard if it implements es.StreamDeleter. Appends are blocked during the
+// migration. Running subscriptions continue with the new shard from its beginning, so
+// they receive the moved events once more.
+func (r *Router) AddShard(store es.EventStore) (*Rebalance, error) {
+	r.Lock()
+	defer r.Unlock()
+	if len(r.shards) >= MaxShards {
+		return nil, evently.Errorf(ErrRebalance, "ErrRebalance", "at most %d shards supported", MaxShards)
+	}
+	multi, ok := store.(es.MultiEventStore)
+	if !ok {
+		return nil, evently.Errorf(ErrRebalance, "ErrRebalance", "[%T] doesn't implement es.MultiEventStore", store)
+	}
+	next := len(r.shards)
+	ring := newRing(next+1, r.vnodes)
+	rebalance := &Rebalance{}
+	moved := make(map[int][]string)
+	changes := make(map[string]map[uint64][]*event.Event)
+	for i, shard := range r.shards {
+		streams, versions, err := streamsOf(shard)
+		if err != nil {
+			return nil, evently.Errorf(ErrRebalance, "ErrRebalance", "list streams of shard %d", i).CausedBy(err)
+		}
+		for _, stream := range streams {
+			owner := ring.shard(stream)
+			if owner == i {
+				continue
+			}
+			history, err := shard.ReadStream(stream)
+			if err != nil {
+				return nil, evently.Errorf(ErrRebalance, "ErrRebalance", "read stream %q", stream).CausedBy(err)
+			}
+			if uint64(len(history)) != versions[stream] {
+				return nil, evently.Errorf(ErrRebalance, "ErrRebalance", "stream %q has %d events at version %d", stream, len(history), versions[stream])
+			}
+			changes[stream] = map[uint64][]*event.Event{0: history}
+			moved[i] = append(moved[i], stream)
+			rebalance.Streams = append(rebalance.Streams, stream)
+			rebalance.Events += len(history)
+		}
+	}
+	if err := multi.AppendMulti(changes); err != nil {
+		return nil, evently.Errorf(ErrRebalance, "ErrRebalance", "move %d streams", len(changes)).CausedBy(err)
+	}
+	r.shards = append(r.shards, store)
+	r.ring = ring
+	for i, streams := range moved {
+		deleter, ok := r.shards[i].(es.StreamDeleter)
+		if !ok {
+			continue
+		}
+		for _, stream := range streams {
+			if err := deleter.DeleteStream(stream); err != nil {
+				return rebalance, evently.Errorf(ErrRebalance, "ErrRebalance", "delete moved stream %q", stream).CausedBy(err)
+			}
+		}
+	}
+	if transport, ok := store.(es.Transport); ok {
+		for s := range r.feeds {
+			s.attach(next, transport.SubscribeWithOffset(0))
+		}
+	}
+	return rebalance, nil
+}
+
+// streamsOf returns all streams of the given store and their latest versions
+func streamsOf(store es.EventStore) ([]string, map[string]uint64, error) {
+	reader, ok := store.(es.LogReader)
+	if !ok {
+		return nil, nil, fmt.Errorf("[%T] doesn't implement es.LogReader", store)
+	}
+	var streams []string
+	versions := make(map[string]uint64)
+	var offset uint64
+	for {
+		entries, err := reader.ReadLog(offset, 1000)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(entries) == 0 {
+			return streams, versions, nil
+		}
+		for _, entry := range entries {
+			if _, seen := versions[entry.Stream]; !seen {
+				streams = append(streams, entry.Stream)
+			}
+			versions[entry.Stream] = entry.Version
+		}
+		offset = entries[len(entries)-1].GlobalPos + 1
+	}
+}
@@ -0,0 +1,178 @@
+package shard_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/command/es/estest"
+	"github.com/openyard/evently/command/es/shard"
+	"github.com/openyard/evently/event"
+	"github.com/openyard/evently/query/consume"
+	"github.com/openyard/evently/query/subscription"
+)
+
+func TestRouter_AddShard(t *testing.T) {
+	r := shard.NewRouter([]es.EventStore{estest.NewTestEventStore(), estest.NewTestEventStore()})
+	for i := 0; i < 20; i++ {
+		stream := fmt.Sprintf("customer-%d", i)
+		_ = r.AppendToStream(stream, 0, event.NewDomainEvent("created", stream), event.NewDomainEvent("activated", stream))
+	}
+	rebalance, err := r.AddShard(estest.NewTestEventStore())
+	if err != nil {
+		t.Fatalf("add shard failed: %s", err)
+	}
+	if len(rebalance.Streams) == 0 || rebalance.Events != 2*len(rebalance.Streams) {
+		t.Errorf("unexpected rebalance: %+v", rebalance)
+	}
+	for _, stream := range rebalance.Streams {
+		if r.Shard(stream) != 2 {
+			t.Errorf("stream %q moved to shard %d", stream, r.Shard(stream))
+		}
+	}
+	for i := 0; i < 20; i++ {
+		stream := fmt.Sprintf("customer-%d", i)
+		history, _ := r.ReadStream(stream)
+		if len(history) != 2 {
+			t.Errorf("stream %q has %d events after rebalance", stream, len(history))
+		}
+		if err := r.AppendToStream(stream, 2, event.NewDomainEvent("blocked", stream)); err != nil {
+			t.Errorf("append to %q after rebalance: %s", stream, err)
+		}
+	}
+
+	var received []*es.Entry
+	s := r.SubscribeFrom(shard.Position{})
+	defer s.Close()
+	for len(received) < 60 {
+		select {
+		case batch := <-s.Entries():
+			received = append(received, batch...)
+		case <-time.After(time.Second):
+			t.Fatalf("received %d of 60 entries", len(received))
+		}
+	}
+	select {
+	case <-s.Live():
+	case <-time.After(time.Second):
+		t.Errorf("merged subscription not live, phase is %s", s.Phase())
+	}
+	p := shard.Position{}.Advance(received...)
+	if len(p) != 3 {
+		t.Errorf("unexpected position %s", p)
+	}
+	parsed, err := shard.ParsePosition(p.String())
+	if err != nil || parsed.String() != p.String() {
+		t.Errorf("position %s parsed as %s: %v", p, parsed, err)
+	}
+}
+
+func TestRouter_AddShard_truncated(t *testing.T) {
+	shards := []*estest.TestEventStore{estest.NewTestEventStore(), estest.NewTestEventStore()}
+	r := shard.NewRouter([]es.EventStore{shards[0], shards[1]})
+	for i := 0; i < 20; i++ {
+		stream := fmt.Sprintf("customer-%d", i)
+		_ = r.AppendToStream(stream, 0, event.NewDomainEvent("created", stream), event.NewDomainEvent("activated", stream))
+		_ = shards[r.Shard(stream)].TruncateStream(stream, 1)
+	}
+	store := estest.NewTestEventStore()
+	if _, err := r.AddShard(store); err == nil {
+		t.Fatal("expected truncated streams not to be moved")
+	}
+	if head, _ := store.Head(); head != 0 {
+		t.Errorf("failed rebalance left %d entries in the new shard", head)
+	}
+	for i := 0; i < 20; i++ {
+		stream := fmt.Sprintf("customer-%d", i)
+		if err := r.AppendToStream(stream, 2, event.NewDomainEvent("blocked", stream)); err != nil {
+			t.Errorf("append to %q after failed rebalance: %s", stream, err)
+		}
+	}
+}
+
+func TestRouter_CatchUpSubscription(t *testing.T) {
+	shards := []es.EventStore{estest.NewTestEventStore(), estest.NewTestEventStore()}
+	r := shard.NewRouter(shards)
+	var mu sync.Mutex
+	consumed := make(map[string]int)
+	checkpoints := make([]*subscription.Checkpoint, len(shards))
+	listen := func() []*subscription.CatchUpSubscription {
+		subs := make([]*subscription.CatchUpSubscription, len(shards))
+		for i, store := range shards {
+			checkpoint := checkpoints[i]
+			subs[i] = subscription.NewCatchUpSubscription(store.(es.Transport),
+				subscription.WithCheckpoint(checkpoint),
+				subscription.WithConsumer(consume.ConsumerFunc(func(_ *consume.Context, entries ...*es.Entry) error {
+					mu.Lock()
+					defer mu.Unlock()
+					for _, entry := range entries {
+						consumed[entry.Event.ID()]++
+					}
+					return nil
+				})),
+				subscription.WithAckFunc(func(entries ...*es.Entry) {
+					checkpoint.Update(entries[len(entries)-1].GlobalPos + 1)
+				}))
+			subs[i].Listen()
+		}
+		return subs
+	}
+	// await waits until the checkpoints of all shards are behind n entries in total
+	await := func(n uint64) {
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			var acked uint64
+			for _, checkpoint := range checkpoints {
+				acked += checkpoint.GlobalPosition()
+			}
+			if acked == n {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	for i := range checkpoints {
+		checkpoints[i] = subscription.NewCheckpoint(fmt.Sprintf("projection-shard-%d", i), 0, time.Now())
+	}
+	for i := 0; i < 10; i++ {
+		stream := fmt.Sprintf("customer-%d", i)
+		_ = r.AppendToStream(stream, 0, event.NewDomainEvent("created", stream))
+	}
+	subs := listen()
+	await(10)
+	for _, s := range subs {
+		s.Stop()
+	}
+	// a restarted consumer resumes every shard at its own checkpoint
+	for i := 0; i < 10; i++ {
+		stream := fmt.Sprintf("customer-%d", i)
+		_ = r.AppendToStream(stream, 1, event.NewDomainEvent("activated", stream))
+	}
+	subs = listen()
+	defer func() {
+		for _, s := range subs {
+			s.Stop()
+		}
+	}()
+	await(20)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(consumed) != 20 {
+		t.Errorf("consumed %d of 20 events", len(consumed))
+	}
+	for id, n := range consumed {
+		if n != 1 {
+			t.Errorf("event %s consumed %d times", id, n)
+		}
+	}
+}
+
+func TestRouter_Conformance(t *testing.T) {
+	estest.TestConformance(t, func() es.EventStore {
+		return shard.NewRouter([]es.EventStore{estest.NewTestEventStore(), estest.NewTestEventStore()})
+	})
+}
@@ -0,0 +1,181 @@
+package shard
+
+import (
+	"sync"
+
+	"github.com/openyard/evently/command/es"
+)
+
+var _ es.Subscription = (*subscription)(nil)
+
+// subscription merges the subscriptions of all shards. It ends when any of them fails
+// or all of them ended.
+type subscription struct {
+	sync.Mutex
+	shards  []es.Subscription
+	active  int
+	ended   bool
+	err     error
+	out     chan []*es.Entry
+	done    chan struct{}
+	once    sync.Once
+	onClose func(s *subscription)
+
+	start      bool
+	catchingUp int
+	caughtUp   chan struct{}
+	liveOnce   sync.Once
+}
+
+func newSubscription(onClose func(s *subscription)) *subscription {
+	s := &subscription{
+		out:      make(chan []*es.Entry),
+		done:     make(chan struct{}),
+		onClose:  onClose,
+		caughtUp: make(chan struct{}),
+	}
+	return s
+}
+
+func (s *subscription) Entries() <-chan []*es.Entry {
+	return s.out
+}
+
+func (s *subscription) Err() error {
+	s.Lock()
+	defer s.Unlock()
+	return s.err
+}
+
+// Phase is Live if the subscriptions of all shards are live
+func (s *subscription) Phase() es.Phase {
+	s.Lock()
+	defer s.Unlock()
+	for _, shard := range s.shards {
+		if shard.Phase() != es.Live {
+			return es.CatchUp
+		}
+	}
+	return es.Live
+}
+
+func (s *subscription) Live() <-chan struct{} {
+	return s.caughtUp
+}
+
+func (s *subscription) Close() {
+	s.once.Do(func() {
+		close(s.done)
+		s.onClose(s)
+	})
+}
+
+// closed reports whether the subscription was closed
+func (s *subscription) closed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// attach merges the subscription of the given shard
+func (s *subscription) attach(shard int, sub es.Subscription) {
+	s.Lock()
+	defer s.Unlock()
+	if s.ended {
+		sub.Close()
+		return
+	}
+	s.shards = append(s.shards, sub)
+	s.active++
+	s.catchingUp++
+	go s.forward(shard, sub)
+	go s.awaitLive(sub)
+}
+
+// forward passes the entries of a shard with encoded global positions to the merged log
+func (s *subscription) forward(shard int, sub es.Subscription) {
+	defer s.detach()
+	defer sub.Close()
+	for {
+		select {
+		case entries, ok := <-sub.Entries():
+			if !ok {
+				if err := sub.Err(); err != nil {
+					s.fail(err)
+				}
+				return
+			}
+			merged := make([]*es.Entry, len(entries))
+			for i, entry := range entries {
+				merged[i] = &es.Entry{
+					GlobalPos: EncodePosition(shard, entry.GlobalPos),
+					Stream:    entry.Stream,
+					Version:   entry.Version,
+					Event:     entry.Event,
+				}
+			}
+			if s.closed() {
+				return
+			}
+			select {
+			case s.out <- merged:
+			case <-s.done:
+				return
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *subscription) fail(err error) {
+	s.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.Unlock()
+	s.Close()
+}
+
+func (s *subscription) detach() {
+	s.Lock()
+	defer s.Unlock()
+	s.active--
+	if s.active == 0 && !s.ended {
+		s.ended = true
+		close(s.out)
+		s.Close()
+	}
+}
+
+// awaitLive counts the subscription of a shard as caught up
+func (s *subscription) awaitLive(sub es.Subscription) {
+	select {
+	case <-sub.Live():
+	case <-s.done:
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.catchingUp--
+	s.checkLive()
+}
+
+// started is called when the subscriptions of all shards known so far are attached
+func (s *subscription) started() {
+	s.Lock()
+	defer s.Unlock()
+	s.start = true
+	s.checkLive()
+}
+
+// checkLive closes the live channel once the subscriptions of all shards caught up.
+// Must be called locked.
+func (s *subscription) checkLive() {
+	if s.start && s.catchingUp == 0 {
+		s.liveOnce.Do(func() { close(s.caughtUp) })
+	}
+}
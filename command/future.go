@@ -0,0 +1,73 @@
+package command
+
+import (
+	"context"
+	"sync"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/event"
+)
+
+// ExecuteFunc executes a command and returns the caused events and the new version of
+// the aggregate
+type ExecuteFunc func(ctx context.Context, c *Command) ([]*event.Event, uint64, error)
+
+// Future is the pending result of a command executed asynchronously
+type Future struct {
+	command *Command
+	once    sync.Once
+	done    chan struct{}
+	events  []*event.Event
+	outcome *Outcome
+}
+
+// NewFuture returns the pending result of the given command
+func NewFuture(c *Command) *Future {
+	return &Future{command: c, done: make(chan struct{})}
+}
+
+// Complete sets the result of the command, only the first call has an effect
+func (f *Future) Complete(events []*event.Event, version uint64, err error) {
+	f.CompleteOutcome(events, NewOutcome(f.command, events, version, err))
+}
+
+// CompleteOutcome sets the result of the command by its outcome, e.g. the recorded
+// outcome of a duplicate without events. Only the first call has an effect.
+func (f *Future) CompleteOutcome(events []*event.Event, outcome *Outcome) {
+	f.once.Do(func() {
+		f.events, f.outcome = events, outcome
+		close(f.done)
+	})
+}
+
+// Done is closed when the result is available
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits for the result of the command: the caused events, the new version of the
+// aggregate and the error of the execution. If ctx is done first, Wait fails with
+// ErrTimeout, the command may still be executed.
+func (f *Future) Wait(ctx context.Context) ([]*event.Event, uint64, error) {
+	select {
+	case <-f.done:
+		return f.events, f.outcome.Version, f.outcome.Err
+	case <-ctx.Done():
+		return nil, 0, TimedOut(f.command, ctx.Err())
+	}
+}
+
+// Outcome waits for the outcome of the command like Wait
+func (f *Future) Outcome(ctx context.Context) *Outcome {
+	select {
+	case <-f.done:
+		return f.outcome
+	case <-ctx.Done():
+		return NewOutcome(f.command, nil, 0, TimedOut(f.command, ctx.Err()))
+	}
+}
+
+// TimedOut returns ErrTimeout of the given command caused by err
+func TimedOut(c *Command, err error) error {
+	return evently.Errorf(ErrTimeout, "ErrTimeout", "[%s] %s not executed in time", c.CommandID(), c.CommandName()).CausedBy(err)
+}
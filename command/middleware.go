@@ -0,0 +1,110 @@
+package command
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/openyard/evently"
+)
+
+// Middleware wraps a HandleFunc with cross-cutting behaviour
+type Middleware func(next HandleFunc) HandleFunc
+
+// Chain composes the given middlewares to one, the first one is the outermost
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next HandleFunc) HandleFunc {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// Recover turns a panic of the handler into an error ErrPanic
+func Recover() Middleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(c *Command) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = evently.Errorf(ErrPanic, "ErrPanic", "[%s] %s: %v", c.CommandID(), c.CommandName(), r)
+				}
+			}()
+			return next(c)
+		}
+	}
+}
+
+// Timeout limits the handling of a command to the given duration by passing it on with
+// an earlier deadline (see WithDeadline). It's cooperative, the handler isn't interrupted:
+// a DomainModel fails a command handled after its deadline, the Service doesn't append
+// the changes of such a command, both with ErrTimeout.
+func Timeout(d time.Duration) Middleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(c *Command) error {
+			return next(c.withDeadline(time.Now().Add(d)))
+		}
+	}
+}
+
+// Validate calls the handler only if the given func accepts the command, otherwise it
+// fails with ErrInvalidCommand
+func Validate(validate func(c *Command) error) Middleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(c *Command) error {
+			if err := validate(c); err != nil {
+				return evently.Errorf(ErrInvalidCommand, "ErrInvalidCommand", "[%s] %s", c.CommandID(), c.CommandName()).CausedBy(err)
+			}
+			return next(c)
+		}
+	}
+}
+
+// Authorize calls the handler only if the given func authorizes the command, e.g. by its
+// user and tenant, otherwise it fails with ErrUnauthorized
+func Authorize(authorize func(c *Command) error) Middleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(c *Command) error {
+			if err := authorize(c); err != nil {
+				return evently.Errorf(ErrUnauthorized, "ErrUnauthorized", "[%s] %s by %q", c.CommandID(), c.CommandName(), c.User()).CausedBy(err)
+			}
+			return next(c)
+		}
+	}
+}
+
+// Logging logs every handled command, failures at level error, successes at level debug
+func Logging(logger *slog.Logger) Middleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(c *Command) error {
+			start := time.Now()
+			err := next(c)
+			attrs := []any{
+				evently.LogCommandName, c.CommandName(),
+				evently.LogCommandID, c.CommandID(),
+				evently.LogAggregateID, c.AggregateID(),
+				slog.Duration("took", time.Since(start)),
+			}
+			if err != nil {
+				logger.Error("command failed", append(attrs, evently.Err(err))...)
+				return err
+			}
+			logger.Debug("command handled", attrs...)
+			return nil
+		}
+	}
+}
+
+// ObserveFunc receives the outcome of a handled command, e.g. to record metrics
+type ObserveFunc func(c *Command, took time.Duration, err error)
+
+// Metrics reports the duration and error of every handled command to the given func
+func Metrics(observe ObserveFunc) Middleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(c *Command) error {
+			start := time.Now()
+			err := next(c)
+			observe(c, time.Since(start), err)
+			return err
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package command_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command"
+	"github.com/openyard/evently/command/es/estest"
+	"github.com/openyard/evently/example"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+	trace := func(name string) command.Middleware {
+		return func(next command.HandleFunc) command.HandleFunc {
+			return func(c *command.Command) error {
+				calls = append(calls, name+">")
+				err := next(c)
+				calls = append(calls, "<"+name)
+				return err
+			}
+		}
+	}
+	h := command.Chain(trace("a"), trace("b"))(func(c *command.Command) error {
+		calls = append(calls, "handle")
+		return nil
+	})
+	_ = h(command.New("noop", "1"))
+	if strings.Join(calls, " ") != "a> b> handle <b <a" {
+		t.Errorf("unexpected order of calls %v", calls)
+	}
+}
+
+func TestMiddlewares(t *testing.T) {
+	failed := errors.New("failed")
+	tests := map[string]struct {
+		middleware command.Middleware
+		handler    command.HandleFunc
+		code       uint
+	}{
+		"Recover": {command.Recover(), func(*command.Command) error { panic("boom") }, command.ErrPanic},
+		"Timeout": {command.Timeout(10 * time.Millisecond), func(c *command.Command) error {
+			ctx, cancel := c.Context(context.Background())
+			defer cancel()
+			<-ctx.Done()
+			return command.TimedOut(c, ctx.Err())
+		}, command.ErrTimeout},
+		"Validate": {command.Validate(func(*command.Command) error { return failed }),
+			func(*command.Command) error { t.Error("invalid command handled"); return nil }, command.ErrInvalidCommand},
+		"Authorize": {command.Authorize(func(c *command.Command) error {
+			if c.User() != "admin" {
+				return failed
+			}
+			return nil
+		}), func(*command.Command) error { t.Error("unauthorized command handled"); return nil }, command.ErrUnauthorized},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tc.middleware(tc.handler)(command.New("block", "1", command.WithUser("jane")))
+			var e *evently.Error
+			if !errors.As(err, &e) || e.Code != tc.code {
+				t.Errorf("expected error %d, got %v", tc.code, err)
+			}
+		})
+	}
+}
+
+func TestLoggingAndMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	var observed []error
+	h := command.Chain(
+		command.Logging(slog.New(slog.NewTextHandler(&buf, nil))),
+		command.Metrics(func(_ *command.Command, _ time.Duration, err error) { observed = append(observed, err) }),
+	)(func(c *command.Command) error {
+		if c.CommandName() == "fail" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	_ = h(command.New("ok", "1"))
+	_ = h(command.New("fail", "2"))
+	if len(observed) != 2 || observed[0] != nil || observed[1] == nil {
+		t.Errorf("unexpected observations %v", observed)
+	}
+	if out := buf.String(); !strings.Contains(out, `msg="command failed" commandName=fail`) || strings.Contains(out, "commandName=ok") {
+		t.Errorf("unexpected log:\n%s", out)
+	}
+}
+
+func TestService_WithMiddleware(t *testing.T) {
+	var handled []string
+	observe := command.Metrics(func(c *command.Command, _ time.Duration, _ error) { handled = append(handled, c.CommandName()) })
+	factory := func() *command.DomainModel {
+		dm := new(example.CustomerFactory).Create()
+		dm.Use(command.Validate(func(c *command.Command) error {
+			if c.AggregateID() == "" {
+				return errors.New("missing aggregate ID")
+			}
+			return nil
+		}))
+		return dm
+	}
+	svc := command.NewService(estest.NewTestEventStore(), factory, command.WithMiddleware(command.Recover(), observe))
+	if err := svc.Process(example.ActivateCustomer("4711")); err != nil {
+		t.Fatal(err)
+	}
+	var e *evently.Error
+	if err := svc.Process(example.ActivateCustomer("")); !errors.As(err, &e) || e.Code != command.ErrInvalidCommand {
+		t.Errorf("expected invalid command from domain model middleware, got %v", err)
+	}
+	if len(handled) != 2 {
+		t.Errorf("expected both commands observed by service middleware, got %v", handled)
+	}
+}
+
+func TestService_Timeout(t *testing.T) {
+	store := estest.NewTestEventStore()
+	factory := func() *command.DomainModel {
+		dm := new(example.CustomerFactory).Create()
+		dm.Use(command.Timeout(10*time.Millisecond), func(next command.HandleFunc) command.HandleFunc {
+			return func(c *command.Command) error {
+				time.Sleep(20 * time.Millisecond) // slow handler
+				return next(c)
+			}
+		})
+		return dm
+	}
+	svc := command.NewService(store, factory)
+	birthdate, _ := time.Parse("2006-01-02", "1999-06-01")
+	var e *evently.Error
+	if err := svc.Process(example.OnboardCustomer("4711", "John Doe", birthdate, 'M')); !errors.As(err, &e) || e.Code != command.ErrTimeout {
+		t.Errorf("expected timeout, got %v", err)
+	}
+	if h, _ := store.ReadStream("4711"); len(h) != 0 {
+		t.Errorf("changes of a timed out command appended: %d events", len(h))
+	}
+}
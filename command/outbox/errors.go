@@ -0,0 +1,6 @@
+package outbox
+
+// error codes
+const (
+	ErrPublish = iota + 9701
+)
@@ -0,0 +1,231 @@
+// Package outbox publishes integration events to a broker once the change of the domain
+// model emitting them is committed.
+//
+// A DomainModel emits integration events like domain events with Causes. The
+// command.Service appends them to the command.OutboxStream of the command together with
+// the domain events, so they are stored atomically with the change without counting for
+// the version of the aggregate. The Relay subscribes to the event store and delivers
+// every event.IntegrationEvent to a Publisher.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/event"
+	"github.com/openyard/evently/query/consume"
+	"github.com/openyard/evently/query/subscription"
+)
+
+const (
+	defaultMinBackoff = 100 * time.Millisecond
+	defaultMaxBackoff = 30 * time.Second
+	defaultLanes      = 8
+)
+
+// Publisher delivers an integration event to a broker
+type Publisher interface {
+	Publish(ctx context.Context, entry *es.Entry) error
+}
+
+// PublisherFunc is a func implementing Publisher
+type PublisherFunc func(ctx context.Context, entry *es.Entry) error
+
+func (f PublisherFunc) Publish(ctx context.Context, entry *es.Entry) error {
+	return f(ctx, entry)
+}
+
+// Option configures a Relay
+type Option func(r *Relay)
+
+// Relay delivers the integration events of an es.Transport to a Publisher. Events of the
+// same aggregate are published in order, events of different aggregates concurrently in
+// a fixed number of lanes. A failed publish is retried with exponential backoff until it
+// succeeds or the relay is stopped.
+//
+// The relay keeps a checkpoint behind its last delivered batch and, while delivering a
+// batch, a mark per lane behind the last published event of the lane. A restarted Relay
+// resumes at its last batch and skips all marked events, so no event is lost or
+// published twice. Only a crash between a publish and storing its mark delivers the
+// event once more.
+type Relay struct {
+	sync.Mutex
+	id          string
+	transport   es.Transport
+	publisher   Publisher
+	checkpoints subscription.CheckpointStore
+	checkpoint  *subscription.Checkpoint
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	lanes       int
+	logger      *slog.Logger
+
+	subscription *subscription.CatchUpSubscription
+}
+
+// NewRelay returns a Relay identified by the given id. The id names the checkpoints of
+// the relay, by default kept in a subscription.MemoryCheckpointStore.
+func NewRelay(id string, transport es.Transport, publisher Publisher, opts ...Option) *Relay {
+	r := &Relay{
+		id:          id,
+		transport:   transport,
+		publisher:   publisher,
+		checkpoints: subscription.NewMemoryCheckpointStore(),
+		minBackoff:  defaultMinBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		lanes:       defaultLanes,
+		logger:      evently.Logger("outbox"),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.checkpoint = r.checkpoints.GetLatestCheckpoint(id)
+	if r.checkpoint == nil {
+		r.checkpoint = subscription.NewCheckpoint(id, 0, time.Time{})
+	}
+	return r
+}
+
+// WithCheckpointStore persists the checkpoints of the relay in the given store
+func WithCheckpointStore(store subscription.CheckpointStore) Option {
+	return func(r *Relay) {
+		r.checkpoints = store
+	}
+}
+
+// WithBackoff sets the wait before the first retry of a failed publish, which doubles
+// with every further retry up to max. The default is 100ms up to 30s.
+func WithBackoff(min, max time.Duration) Option {
+	return func(r *Relay) {
+		r.minBackoff = min
+		r.maxBackoff = max
+	}
+}
+
+// WithLanes sets the number of lanes publishing concurrently, default is 8. Changing it
+// between restarts delivers the events of the last batch once more.
+func WithLanes(n int) Option {
+	return func(r *Relay) {
+		r.lanes = n
+	}
+}
+
+// WithLogger sets the logger of the relay and its subscription, default is the evently
+// logger of component "outbox"
+func WithLogger(logger *slog.Logger) Option {
+	return func(r *Relay) {
+		r.logger = logger
+	}
+}
+
+// Start starts to deliver from the checkpoint of the relay
+func (r *Relay) Start() {
+	r.Lock()
+	defer r.Unlock()
+	r.subscription = subscription.NewCatchUpSubscription(r.transport,
+		subscription.WithCheckpoint(r.checkpoint),
+		subscription.WithConsumer(consume.ConsumerFunc(r.relay)),
+		subscription.WithLogger(r.logger))
+	r.subscription.Listen()
+}
+
+// Stop stops to deliver and cancels running retries
+func (r *Relay) Stop() {
+	r.Lock()
+	defer r.Unlock()
+	if r.subscription != nil {
+		r.subscription.Stop()
+		r.subscription = nil
+	}
+}
+
+// relay publishes the integration events of the batch in lanes by aggregate and moves the
+// checkpoint behind the batch once all are delivered
+func (r *Relay) relay(ctx *consume.Context, entries ...*es.Entry) error {
+	lanes := make([][]*es.Entry, r.lanes)
+	for _, entry := range entries {
+		if entry.GlobalPos < r.checkpoint.GlobalPosition() || entry.Event.Kind() != event.IntegrationEvent {
+			continue
+		}
+		lane := r.lane(entry.Event.AggregateID())
+		lanes[lane] = append(lanes[lane], entry)
+	}
+	errs := make([]error, len(lanes))
+	var wg sync.WaitGroup
+	for i, pending := range lanes {
+		if len(pending) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, pending []*es.Entry) {
+			defer wg.Done()
+			errs[i] = r.deliver(ctx, i, pending)
+		}(i, pending)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	if len(entries) > 0 {
+		r.checkpoint.Update(entries[len(entries)-1].GlobalPos + 1)
+		r.checkpoints.StoreCheckpoint(r.checkpoint)
+	}
+	return nil
+}
+
+// lane returns the lane publishing the events of the aggregate
+func (r *Relay) lane(aggregateID string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(aggregateID))
+	return int(h.Sum32() % uint32(r.lanes))
+}
+
+// deliver publishes the entries of a lane in order, skips entries marked as delivered
+// and marks each published entry. The mark is named by the number of lanes, as the
+// entries of a lane depend on it.
+func (r *Relay) deliver(ctx context.Context, lane int, entries []*es.Entry) error {
+	id := fmt.Sprintf("%s-lane-%d-of-%d", r.id, lane, r.lanes)
+	mark := r.checkpoints.GetLatestCheckpoint(id)
+	if mark == nil {
+		mark = subscription.NewCheckpoint(id, 0, time.Time{})
+	}
+	for _, entry := range entries {
+		if entry.GlobalPos < mark.GlobalPosition() {
+			continue // delivered before restart
+		}
+		if err := r.publish(ctx, entry); err != nil {
+			return err
+		}
+		r.checkpoints.StoreCheckpoint(subscription.NewCheckpoint(id, entry.GlobalPos+1, time.Now()))
+	}
+	return nil
+}
+
+// publish retries to publish the entry until it succeeds or the context is done
+func (r *Relay) publish(ctx context.Context, entry *es.Entry) error {
+	backoff := r.minBackoff
+	for {
+		err := r.publisher.Publish(ctx, entry)
+		if err == nil {
+			return nil
+		}
+		r.logger.Error("publish failed", evently.LogSubscription, r.id, evently.LogStream, entry.Stream,
+			evently.LogGlobalPos, entry.GlobalPos, "eventID", entry.Event.ID(), "retryIn", backoff, evently.Err(err))
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return evently.Errorf(ErrPublish, "ErrPublish", "event %s of stream %q", entry.Event.ID(), entry.Stream).CausedBy(err)
+		}
+		if backoff *= 2; backoff > r.maxBackoff {
+			backoff = r.maxBackoff
+		}
+	}
+}
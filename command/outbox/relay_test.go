@@ -0,0 +1,114 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/command/es/estest"
+	"github.com/openyard/evently/command/outbox"
+	"github.com/openyard/evently/event"
+	"github.com/openyard/evently/query/subscription"
+)
+
+func TestRelay(t *testing.T) {
+	store := estest.NewTestEventStore()
+	_ = store.AppendToStream("customer-1", 0,
+		event.NewDomainEvent("created", "1"), event.NewIntegrationEvent("customer-created", "1"))
+	_ = store.AppendToStream("customer-2", 0,
+		event.NewDomainEvent("created", "2"), event.NewIntegrationEvent("customer-created", "2"))
+	_ = store.AppendToStream("customer-1", 2,
+		event.NewDomainEvent("blocked", "1"), event.NewIntegrationEvent("customer-blocked", "1"))
+
+	failures := 2
+	broker := &broker{fail: func(entry *es.Entry) bool {
+		if entry.Stream == "customer-1" && failures > 0 {
+			failures--
+			return true
+		}
+		return false
+	}}
+	relay := outbox.NewRelay("relay", store, broker, outbox.WithBackoff(time.Millisecond, time.Millisecond))
+	relay.Start()
+	defer relay.Stop()
+
+	published := broker.await(t, 3)
+	if got := published["customer-1"]; len(got) != 2 || got[0] != "customer-created" || got[1] != "customer-blocked" {
+		t.Errorf("unexpected events of customer-1: %v", got)
+	}
+	if got := published["customer-2"]; len(got) != 1 || got[0] != "customer-created" {
+		t.Errorf("unexpected events of customer-2: %v", got)
+	}
+}
+
+func TestRelay_restart(t *testing.T) {
+	store := estest.NewTestEventStore()
+	_ = store.AppendToStream("customer-1", 0, event.NewIntegrationEvent("customer-created", "1"))
+	_ = store.AppendToStream("customer-2", 0, event.NewIntegrationEvent("customer-created", "2"))
+	checkpoints := subscription.NewMemoryCheckpointStore()
+
+	down := &broker{fail: func(entry *es.Entry) bool { return entry.Stream == "customer-2" }}
+	relay := outbox.NewRelay("relay", store, down,
+		outbox.WithCheckpointStore(checkpoints), outbox.WithBackoff(time.Millisecond, time.Millisecond))
+	relay.Start()
+	down.await(t, 1)
+	relay.Stop()
+
+	up := &broker{}
+	relay = outbox.NewRelay("relay", store, up, outbox.WithCheckpointStore(checkpoints))
+	relay.Start()
+	defer relay.Stop()
+	_ = store.AppendToStream("customer-1", 1, event.NewIntegrationEvent("customer-blocked", "1"))
+
+	published := up.await(t, 2)
+	if got := published["customer-1"]; len(got) != 1 || got[0] != "customer-blocked" {
+		t.Errorf("unexpected events of customer-1 after restart: %v", got)
+	}
+	if got := published["customer-2"]; len(got) != 1 {
+		t.Errorf("unexpected events of customer-2 after restart: %v", got)
+	}
+}
+
+// broker records the published events per stream and fails publishing if fail says so
+type broker struct {
+	sync.Mutex
+	fail      func(entry *es.Entry) bool
+	published map[string][]string
+	count     int
+}
+
+func (b *broker) Publish(_ context.Context, entry *es.Entry) error {
+	b.Lock()
+	defer b.Unlock()
+	if b.fail != nil && b.fail(entry) {
+		return errors.New("broker unavailable")
+	}
+	if b.published == nil {
+		b.published = make(map[string][]string)
+	}
+	b.published[entry.Stream] = append(b.published[entry.Stream], entry.Event.Name())
+	b.count++
+	return nil
+}
+
+func (b *broker) await(t *testing.T, n int) map[string][]string {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		b.Lock()
+		count, published := b.count, b.published
+		b.Unlock()
+		if count >= n {
+			time.Sleep(10 * time.Millisecond) // catch events published twice
+			b.Lock()
+			defer b.Unlock()
+			return published
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("published %d of %d events", b.count, n)
+	return nil
+}
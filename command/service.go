@@ -1,31 +1,205 @@
 package command
 
-import "github.com/openyard/evently/command/es"
+import (
+	"context"
+	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/event"
+)
 
 type CreateFunc func() *DomainModel
 
+type ServiceOption func(cs *Service)
+
 type Service struct {
-	es es.EventStore
-	cf CreateFunc
+	es     es.EventStore
+	cf     CreateFunc
+	tracer Tracer
+	handle Middleware
+	dedup  *Deduplicator
+}
+
+func NewService(es es.EventStore, cf CreateFunc, opts ...ServiceOption) *Service {
+	cs := &Service{
+		es:     es,
+		cf:     cf,
+		tracer: noTracer{},
+		handle: Chain(),
+	}
+	for _, opt := range opts {
+		opt(cs)
+	}
+	return cs
+}
+
+// WithTracer traces the processed commands with the given Tracer, default is no tracing
+func WithTracer(t Tracer) ServiceOption {
+	return func(cs *Service) {
+		cs.tracer = t
+	}
+}
+
+// WithMiddleware wraps the processing of every command, i.e. loading the domain model,
+// executing the command and appending the changes, with the given middlewares
+func WithMiddleware(middlewares ...Middleware) ServiceOption {
+	return func(cs *Service) {
+		cs.handle = Chain(middlewares...)
+	}
 }
 
-func NewService(es es.EventStore, cf CreateFunc) *Service {
-	return &Service{
-		es: es,
-		cf: cf,
+// WithDeduplication processes every command ID once, a repeated command gets the outcome
+// of its first processing
+func WithDeduplication(d *Deduplicator) ServiceOption {
+	return func(cs *Service) {
+		cs.dedup = d
 	}
 }
 
+// Commands returns the names of all commands the domain model of the service handles
+func (cs *Service) Commands() []string {
+	return cs.cf().Commands()
+}
+
+// Process executes the command synchronously
 func (cs *Service) Process(cmd *Command) error {
-	h, err := cs.es.ReadStream(cmd.AggregateID())
+	return cs.ProcessContext(context.Background(), cmd)
+}
+
+// ProcessContext processes the command as part of the trace in ctx. The trace context is
+// persisted in the metadata of the caused events, see WithTracer.
+func (cs *Service) ProcessContext(ctx context.Context, cmd *Command) error {
+	return cs.ProcessOutcome(ctx, cmd).Err
+}
+
+// ProcessOutcome processes the command like ProcessContext and returns its outcome
+func (cs *Service) ProcessOutcome(ctx context.Context, cmd *Command) *Outcome {
+	_, outcome := cs.execute(ctx, cmd)
+	return outcome
+}
+
+// Execute processes the command like ProcessContext and returns the caused events and the
+// new version of the aggregate. It's an ExecuteFunc. A duplicate command returns no events.
+func (cs *Service) Execute(ctx context.Context, cmd *Command) ([]*event.Event, uint64, error) {
+	events, outcome := cs.execute(ctx, cmd)
+	return events, outcome.Version, outcome.Err
+}
+
+// execute processes the command until the deadline of ctx or the command
+func (cs *Service) execute(ctx context.Context, cmd *Command) (events []*event.Event, outcome *Outcome) {
+	ctx, cancel := cmd.Context(ctx)
+	defer cancel()
+	ctx, end := cs.tracer.StartProcess(ctx, cmd)
+	defer func() {
+		if outcome == nil { // panicked
+			end(nil)
+			return
+		}
+		end(outcome.Err)
+	}()
+	process := func(cmd *Command) *Outcome {
+		var version uint64
+		err := cs.handle(func(cmd *Command) (err error) {
+			ctx, cancel := cmd.Context(ctx) // deadline as passed on by the middlewares
+			defer cancel()
+			events, version, err = cs.process(ctx, cmd)
+			return err
+		})(cmd)
+		return NewOutcome(cmd, events, version, err)
+	}
+	if cs.dedup != nil {
+		return events, cs.dedup.Process(cmd, process)
+	}
+	return events, process(cmd)
+}
+
+func (cs *Service) process(ctx context.Context, cmd *Command) ([]*event.Event, uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, TimedOut(cmd, err)
+	}
+	h, err := cs.readStream(ctx, cmd.AggregateID())
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 	dm := cs.cf()
 	dm.Load(h)
 	changes, err := dm.Execute(cmd)
 	if err != nil {
-		return err
+		return nil, 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, 0, TimedOut(cmd, err)
+	}
+	changes = cs.tracer.Inject(ctx, changes...)
+	if err := cs.appendChanges(ctx, cmd, changes); err != nil {
+		return nil, 0, err
+	}
+	return changes, dm.Version(), nil
+}
+
+// OutboxStream returns the stream of the integration events caused by the given command.
+// They are kept apart from the stream of the aggregate, so they don't count for its version.
+func OutboxStream(cmd *Command) string {
+	return "outbox-" + cmd.CommandID()
+}
+
+// appendChanges appends the domain events to the stream of the aggregate. Integration
+// events are appended to the OutboxStream of the command at once, which requires an
+// es.MultiEventStore.
+func (cs *Service) appendChanges(ctx context.Context, cmd *Command, changes es.History) error {
+	var domainEvents, integrationEvents es.History
+	for _, e := range changes {
+		if e.Kind() == event.IntegrationEvent {
+			integrationEvents = append(integrationEvents, e)
+			continue
+		}
+		domainEvents = append(domainEvents, e)
+	}
+	if len(integrationEvents) == 0 {
+		return cs.appendToStream(ctx, cmd.AggregateID(), cmd.ExpectedVersion(), domainEvents)
+	}
+	multi, ok := cs.es.(es.MultiEventStore)
+	if !ok {
+		return evently.Errorf(es.ErrUnsupported, "ErrUnsupported", "[%T] appending integration events", cs.es)
+	}
+	end := cs.tracer.StartAppendToStream(ctx, cmd.AggregateID(), cmd.ExpectedVersion(), len(changes))
+	err := multi.AppendMulti(map[string]map[uint64][]*event.Event{
+		cmd.AggregateID(): {cmd.ExpectedVersion(): domainEvents},
+		OutboxStream(cmd): {0: integrationEvents},
+	})
+	end(err)
+	return err
+}
+
+func (cs *Service) readStream(ctx context.Context, stream string) (es.History, error) {
+	end := cs.tracer.StartReadStream(ctx, stream)
+	h, err := cs.es.ReadStream(stream)
+	end(len(h), err)
+	return h, err
+}
+
+func (cs *Service) appendToStream(ctx context.Context, stream string, expectedVersion uint64, changes es.History) error {
+	end := cs.tracer.StartAppendToStream(ctx, stream, expectedVersion, len(changes))
+	err := cs.es.AppendToStream(stream, expectedVersion, changes...)
+	end(err)
+	return err
+}
+
+// LoadAsOf loads the domain model with all events occurred before the given point in time
+// as known at the given transaction time. The event store must implement es.BitemporalReader.
+func (cs *Service) LoadAsOf(aggregateID string, at, knownAt time.Time) (*DomainModel, error) {
+	r, ok := cs.es.(es.BitemporalReader)
+	if !ok {
+		return nil, evently.Errorf(es.ErrUnsupported, "ErrUnsupported", "[%T] bitemporal reads", cs.es)
+	}
+	h, err := r.ReadStreamAsOf(aggregateID, at, knownAt)
+	if err != nil {
+		return nil, err
+	}
+	dm := cs.cf()
+	if len(h) > 0 {
+		dm.Load(h)
 	}
-	return cs.es.AppendToStream(cmd.AggregateID(), cmd.ExpectedVersion(), changes...)
+	return dm, nil
 }
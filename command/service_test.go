@@ -0,0 +1,53 @@
+package command_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openyard/evently/command"
+	"github.com/openyard/evently/command/es/estest"
+	"github.com/openyard/evently/event"
+	"github.com/openyard/evently/example"
+)
+
+func TestService_integrationEvents(t *testing.T) {
+	create := func() *command.DomainModel {
+		dm := &command.DomainModel{}
+		dm.Init("Account", map[string]command.Transition{"opened": func(*event.Event) {}},
+			map[string]command.HandleFunc{"open": func(c *command.Command) error {
+				dm.Causes(event.NewDomainEvent("opened", c.AggregateID()),
+					event.NewIntegrationEvent("account-opened", c.AggregateID()))
+				return nil
+			}})
+		return dm
+	}
+	store := estest.NewTestEventStore()
+	svc := command.NewService(store, create)
+	open := command.New("open", "4711")
+	outcome := svc.ProcessOutcome(context.Background(), open)
+	if outcome.Err != nil || outcome.Version != 1 || len(outcome.EventIDs) != 2 {
+		t.Fatalf("unexpected outcome %+v", outcome)
+	}
+	if h, _ := store.ReadStream("4711"); len(h) != 1 || h[0].Kind() != event.DomainEvent {
+		t.Errorf("expected only the domain event in the stream of the aggregate, got %+v", h)
+	}
+	if h, _ := store.ReadStream(command.OutboxStream(open)); len(h) != 1 || h[0].Kind() != event.IntegrationEvent {
+		t.Errorf("expected the integration event in the outbox stream, got %+v", h)
+	}
+	if err := svc.Process(command.New("open", "4711", command.WithExpectedVersion(1))); err != nil {
+		t.Errorf("expected version not bumped by the integration event: %s", err)
+	}
+}
+
+func TestService_Execute(t *testing.T) {
+	svc := command.NewService(estest.NewTestEventStore(), new(example.CustomerFactory).Create)
+	events, version, err := svc.Execute(context.Background(), example.ActivateCustomer("4711"))
+	if err != nil || len(events) != 1 || version != 1 {
+		t.Errorf("unexpected result %+v at version %d: %v", events, version, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := svc.Execute(ctx, example.ActivateCustomer("4712")); err == nil {
+		t.Error("expected done context to fail")
+	}
+}
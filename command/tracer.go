@@ -0,0 +1,40 @@
+package command
+
+import (
+	"context"
+
+	"github.com/openyard/evently/event"
+)
+
+// Tracer instruments the processing of commands, e.g. module
+// github.com/openyard/evently/pkg/tracing with OpenTelemetry. Every Start method returns
+// the func which ends the started operation.
+type Tracer interface {
+	// StartProcess starts processing the command and returns the context of the processing
+	StartProcess(ctx context.Context, cmd *Command) (context.Context, func(err error))
+	// StartReadStream starts reading the stream, its end gets the number of events read
+	StartReadStream(ctx context.Context, stream string) func(events int, err error)
+	// StartAppendToStream starts appending the number of events to the stream
+	StartAppendToStream(ctx context.Context, stream string, expectedVersion uint64, events int) func(err error)
+	// Inject returns the events with the trace context of ctx persisted in their metadata
+	Inject(ctx context.Context, events ...*event.Event) []*event.Event
+}
+
+// noTracer is the Tracer of a Service without tracing
+type noTracer struct{}
+
+func (noTracer) StartProcess(ctx context.Context, _ *Command) (context.Context, func(err error)) {
+	return ctx, func(error) {}
+}
+
+func (noTracer) StartReadStream(context.Context, string) func(int, error) {
+	return func(int, error) {}
+}
+
+func (noTracer) StartAppendToStream(context.Context, string, uint64, int) func(error) {
+	return func(error) {}
+}
+
+func (noTracer) Inject(_ context.Context, events ...*event.Event) []*event.Event {
+	return events
+}
@@ -2,6 +2,7 @@ package event
 
 import (
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/openyard/evently/pkg/uuid"
@@ -12,6 +13,14 @@ const (
 	IntegrationEvent      = "IntegrationEvent"
 )
 
+// Metadata keys of the command which caused an event
+const (
+	// MetaCausationID is the ID of the command which caused the event
+	MetaCausationID = "causationId"
+	// MetaCorrelationID is the ID of the conversation the event belongs to
+	MetaCorrelationID = "correlationId"
+)
+
 // Type ...
 type Type string
 
@@ -29,7 +38,9 @@ type Event struct {
 	name        string
 	aggregateID string
 	payload     []byte
+	metadata    map[string]string
 	occurredAt  time.Time
+	recordedAt  time.Time
 }
 
 // NewDomainEvent initializes a domain event with given name and aggregateID
@@ -71,6 +82,18 @@ func WithPayload(payload []byte) Option {
 	}
 }
 
+// WithMetadata adds the given key-value pairs to the metadata of the event
+func WithMetadata(metadata map[string]string) Option {
+	return func(e *Event) {
+		if e.metadata == nil {
+			e.metadata = make(map[string]string, len(metadata))
+		}
+		for k, v := range metadata {
+			e.metadata[k] = v
+		}
+	}
+}
+
 func WithEventType(kind Type) Option {
 	return func(e *Event) {
 		e.kind = kind
@@ -102,11 +125,60 @@ func (e *Event) Payload() []byte {
 	return e.payload
 }
 
+// Metadata ...
+func (e *Event) Metadata() map[string]string {
+	return e.metadata
+}
+
+// CausationID returns the ID of the command which caused the event, if known
+func (e *Event) CausationID() string {
+	return e.metadata[MetaCausationID]
+}
+
+// CorrelationID returns the ID of the conversation the event belongs to, if known
+func (e *Event) CorrelationID() string {
+	return e.metadata[MetaCorrelationID]
+}
+
+// Annotate returns a copy of the event with the given metadata added. Keys already set
+// keep their value.
+func (e *Event) Annotate(metadata map[string]string) *Event {
+	annotated := *e
+	annotated.metadata = make(map[string]string, len(e.metadata)+len(metadata))
+	for k, v := range metadata {
+		annotated.metadata[k] = v
+	}
+	for k, v := range e.metadata {
+		annotated.metadata[k] = v
+	}
+	return &annotated
+}
+
 // OccurredAt ...
 func (e *Event) OccurredAt() time.Time {
 	return e.occurredAt
 }
 
+// RecordedAt returns the transaction time, the time the event was recorded in an
+// event store. It's zero as long as the event isn't recorded. OccurredAt instead is
+// the valid time, the time the event happened in the domain.
+func (e *Event) RecordedAt() time.Time {
+	return e.recordedAt
+}
+
+// Record returns a copy of the event recorded at the given transaction time
+func (e *Event) Record(at time.Time) *Event {
+	recorded := *e
+	recorded.recordedAt = at.UTC()
+	if e.metadata != nil {
+		recorded.metadata = make(map[string]string, len(e.metadata))
+		for k, v := range e.metadata {
+			recorded.metadata[k] = v
+		}
+	}
+	return &recorded
+}
+
 // MarshalJSON is implementation of json.Marshaler
 func (e *Event) MarshalJSON() ([]byte, error) {
 	v := map[string]any{
@@ -117,16 +189,42 @@ func (e *Event) MarshalJSON() ([]byte, error) {
 		"Payload":     e.payload,
 		"OccurredAt":  e.occurredAt,
 	}
+	if len(e.metadata) > 0 {
+		v["Metadata"] = e.metadata
+	}
+	if !e.recordedAt.IsZero() {
+		v["RecordedAt"] = e.recordedAt
+	}
 	return json.MarshalIndent(v, "", "  ")
 }
 
-// UnmarshalJSON is implementation of json.Unmarshaler
+// UnmarshalJSON is implementation of json.Unmarshaler. It fails if the name, ID,
+// aggregateID or occurredAt of the event is missing.
 func (e *Event) UnmarshalJSON(data []byte) error {
-	var v map[string]any
+	var v struct {
+		Kind        string
+		Name        *string
+		ID          *string
+		AggregateID *string
+		Payload     []byte
+		Metadata    map[string]string
+		OccurredAt  *time.Time
+		RecordedAt  time.Time
+	}
 	if err := json.Unmarshal(data, &v); err != nil {
 		return err
 	}
-	switch v["Kind"].(string) {
+	switch {
+	case v.Name == nil:
+		return errors.New("event without Name")
+	case v.ID == nil:
+		return errors.New("event without ID")
+	case v.AggregateID == nil:
+		return errors.New("event without AggregateID")
+	case v.OccurredAt == nil:
+		return errors.New("event without OccurredAt")
+	}
+	switch v.Kind {
 	case "IntegrationEvent":
 		e.kind = IntegrationEvent
 	case "DomainEvent":
@@ -134,10 +232,12 @@ func (e *Event) UnmarshalJSON(data []byte) error {
 	default:
 		e.kind = DomainEvent
 	}
-	e.name = v["Name"].(string)
-	e.id = v["ID"].(string)
-	e.aggregateID = v["AggregateID"].(string)
-	e.payload = []byte(v["Payload"].(string))
-	e.occurredAt, _ = time.Parse(time.RFC3339Nano, v["OccurredAt"].(string))
+	e.name = *v.Name
+	e.id = *v.ID
+	e.aggregateID = *v.AggregateID
+	e.payload = v.Payload
+	e.metadata = v.Metadata
+	e.occurredAt = *v.OccurredAt
+	e.recordedAt = v.RecordedAt
 	return nil
 }
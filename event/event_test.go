@@ -50,6 +50,13 @@ func TestEvent_UnmarshalJSON(t *testing.T) {
 
 }
 
+func TestEvent_UnmarshalJSON_missing(t *testing.T) {
+	var e event.Event
+	if err := e.UnmarshalJSON([]byte(`{"ID": "0815", "Name": "test-event"}`)); err == nil {
+		t.Error("expected error for event without AggregateID and OccurredAt")
+	}
+}
+
 var expected = `{
   "AggregateID": "4711",
   "ID": "0815",
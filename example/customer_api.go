@@ -1,6 +1,7 @@
 package example
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -29,9 +30,13 @@ func NewCustomerAPI(cs *command.Service, opts ...APIOption) *CustomerAPI {
 
 func WithAsyncWriteSide() APIOption {
 	return func(a *CustomerAPI) {
-		q := async.NewCommandQueue(2, async.WithQueueTimeout(time.Millisecond*200))
+		q := async.NewCommandQueue(2)
 		q.Register(command.HandleFunc(a.cs))
-		a.cs = q.Send
+		a.cs = func(c *command.Command) error {
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			return q.SendContext(ctx, c)
+		}
 	}
 }
 
@@ -1,12 +1,111 @@
 package evently
 
 import (
-	"log"
-	"os"
+	"context"
+	"log/slog"
+	"sync"
 )
 
-func DEBUG(format string, args ...interface{}) {
-	if os.Getenv("DEBUG") != "" {
-		log.Printf(format, args...)
+// Log attribute keys used consistently by all components
+const (
+	LogComponent    = "component"
+	LogStream       = "stream"
+	LogAggregateID  = "aggregateID"
+	LogCommandID    = "commandID"
+	LogCommandName  = "commandName"
+	LogGlobalPos    = "globalPos"
+	LogSubscription = "subscription"
+	LogTopic        = "topic"
+	LogError        = "error"
+)
+
+// logging is the library-wide configuration of all component loggers. It discards all
+// records by default, libraries stay silent unless the application sets a handler.
+var logging = struct {
+	sync.RWMutex
+	handler slog.Handler
+	level   slog.Leveler
+	levels  map[string]slog.Leveler
+}{handler: discardHandler{}, level: slog.LevelInfo, levels: make(map[string]slog.Leveler)}
+
+// SetLogHandler sets the handler of all component loggers, nil discards all records
+func SetLogHandler(h slog.Handler) {
+	if h == nil {
+		h = discardHandler{}
 	}
+	logging.Lock()
+	defer logging.Unlock()
+	logging.handler = h
+}
+
+// SetLogLevel sets the minimum level of all components without an own level, default is info
+func SetLogLevel(level slog.Leveler) {
+	logging.Lock()
+	defer logging.Unlock()
+	logging.level = level
+}
+
+// SetComponentLogLevel sets the minimum level of the named component, e.g. "subscription"
+func SetComponentLogLevel(component string, level slog.Leveler) {
+	logging.Lock()
+	defer logging.Unlock()
+	logging.levels[component] = level
 }
+
+// Logger returns the logger of the named component. It follows later changes of the
+// handler and levels, so components may take their logger at construction.
+func Logger(component string) *slog.Logger {
+	return slog.New(&componentHandler{component: component})
+}
+
+// Err returns the error as log attribute
+func Err(err error) slog.Attr {
+	return slog.Any(LogError, err)
+}
+
+// componentHandler delegates to the configured handler, filtered by the level of its component
+type componentHandler struct {
+	component string
+	with      []func(h slog.Handler) slog.Handler
+}
+
+func (h *componentHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	logging.RLock()
+	defer logging.RUnlock()
+	min, ok := logging.levels[h.component]
+	if !ok {
+		min = logging.level
+	}
+	return level >= min.Level() && logging.handler.Enabled(ctx, level)
+}
+
+func (h *componentHandler) Handle(ctx context.Context, r slog.Record) error {
+	logging.RLock()
+	handler := logging.handler
+	logging.RUnlock()
+	handler = handler.WithAttrs([]slog.Attr{slog.String(LogComponent, h.component)})
+	for _, with := range h.with {
+		handler = with(handler)
+	}
+	return handler.Handle(ctx, r)
+}
+
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.extend(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	return h.extend(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h *componentHandler) extend(with func(h slog.Handler) slog.Handler) *componentHandler {
+	return &componentHandler{component: h.component, with: append(h.with[:len(h.with):len(h.with)], with)}
+}
+
+// discardHandler discards all records
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
@@ -0,0 +1,37 @@
+package evently_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/openyard/evently"
+)
+
+func TestLogger(t *testing.T) {
+	logger := evently.Logger("subscription").With(evently.LogSubscription, "projection")
+	logger.Error("silent by default")
+
+	var buf bytes.Buffer
+	evently.SetLogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	defer evently.SetLogHandler(nil)
+	logger.Debug("below default level")
+	logger.Info("listening", evently.LogGlobalPos, 42)
+	evently.SetComponentLogLevel("subscription", slog.LevelDebug)
+	defer evently.SetComponentLogLevel("subscription", slog.LevelInfo)
+	logger.Debug("checkpoint updated")
+	evently.Logger("async").Debug("other component stays at info")
+
+	out := buf.String()
+	if strings.Contains(out, "silent by default") || strings.Contains(out, "below default level") ||
+		strings.Contains(out, "other component") {
+		t.Errorf("unexpected records logged:\n%s", out)
+	}
+	if !strings.Contains(out, "msg=listening component=subscription subscription=projection globalPos=42") {
+		t.Errorf("expected record with consistent attributes, got:\n%s", out)
+	}
+	if !strings.Contains(out, `msg="checkpoint updated"`) {
+		t.Errorf("expected debug record of component, got:\n%s", out)
+	}
+}
@@ -6,31 +6,53 @@ import (
 	"time"
 )
 
+// TemporalCollection keeps items by valid time and transaction time. The valid time
+// is the time an item is valid from, the transaction time is the time the item became
+// known. Items put without transaction time are known since ever.
 type TemporalCollection struct {
-	_milestonesCache timeSlice
-	contents         map[time.Time]interface{}
+	_milestonesCache milestones
+	contents         []milestone
+}
+
+type milestone struct {
+	validAt    time.Time
+	recordedAt time.Time
+	seq        int
+	item       interface{}
 }
 
 func NewTemporalCollection() *TemporalCollection {
-	return &TemporalCollection{contents: make(map[time.Time]interface{})}
+	return &TemporalCollection{}
 }
 
+// Put adds the item valid from the given time
 func (tc *TemporalCollection) Put(at time.Time, item interface{}) {
-	tc.contents[at] = item
+	tc.PutRecorded(at, time.Time{}, item)
+}
+
+// PutRecorded adds the item valid from the given time and known since recordedAt
+func (tc *TemporalCollection) PutRecorded(at, recordedAt time.Time, item interface{}) {
+	tc.contents = append(tc.contents, milestone{validAt: at, recordedAt: recordedAt, seq: len(tc.contents), item: item})
 	tc.clearMilestoneCache()
 }
 
+// Get returns the item valid at the given time as currently known
 func (tc *TemporalCollection) Get(when time.Time) (interface{}, error) {
-	for _, date := range tc.milestones() {
-		thisDate := date
-		if thisDate.Before(when) || thisDate.Equal(when) {
-			return tc.contents[thisDate], nil
+	return tc.GetAsOf(when, future)
+}
+
+// GetAsOf returns the item valid at the given time as known at the given transaction time
+func (tc *TemporalCollection) GetAsOf(when, knownAt time.Time) (interface{}, error) {
+	for _, m := range tc.milestones() {
+		if m.validAt.After(when) || m.recordedAt.After(knownAt) {
+			continue
 		}
+		return m.item, nil
 	}
 	return nil, fmt.Errorf("no records that early")
 }
 
-func (tc *TemporalCollection) milestones() timeSlice {
+func (tc *TemporalCollection) milestones() milestones {
 	if tc._milestonesCache == nil {
 		tc.calculateMilestones()
 	}
@@ -38,10 +60,8 @@ func (tc *TemporalCollection) milestones() timeSlice {
 }
 
 func (tc *TemporalCollection) calculateMilestones() {
-	tc._milestonesCache = make(timeSlice, 0, len(tc.contents))
-	for k := range tc.contents {
-		tc._milestonesCache = append(tc._milestonesCache, k)
-	}
+	tc._milestonesCache = make(milestones, len(tc.contents))
+	copy(tc._milestonesCache, tc.contents)
 	sort.Sort(sort.Reverse(tc._milestonesCache))
 }
 
@@ -49,16 +69,23 @@ func (tc *TemporalCollection) clearMilestoneCache() {
 	tc._milestonesCache = nil
 }
 
-type timeSlice []time.Time
+// milestones are ordered by valid time, transaction time and insertion
+type milestones []milestone
 
-func (ts timeSlice) Len() int {
-	return len(ts)
+func (ms milestones) Len() int {
+	return len(ms)
 }
 
-func (ts timeSlice) Less(i, j int) bool {
-	return ts[i].Before(ts[j])
+func (ms milestones) Less(i, j int) bool {
+	if !ms[i].validAt.Equal(ms[j].validAt) {
+		return ms[i].validAt.Before(ms[j].validAt)
+	}
+	if !ms[i].recordedAt.Equal(ms[j].recordedAt) {
+		return ms[i].recordedAt.Before(ms[j].recordedAt)
+	}
+	return ms[i].seq < ms[j].seq
 }
 
-func (ts timeSlice) Swap(i, j int) {
-	ts[i], ts[j] = ts[j], ts[i]
+func (ms milestones) Swap(i, j int) {
+	ms[i], ms[j] = ms[j], ms[i]
 }
@@ -0,0 +1,43 @@
+package timeutil_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openyard/evently/pkg/timeutil"
+)
+
+func TestTemporalCollection_GetAsOf(t *testing.T) {
+	jan := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tc := timeutil.NewTemporalCollection()
+	tc.PutRecorded(jan, jan, "address-1")
+	tc.PutRecorded(feb, mar, "address-2") // retroactive correction recorded in march
+
+	tests := []struct {
+		at, knownAt time.Time
+		expected    string
+	}{
+		{feb, feb, "address-1"},
+		{feb, mar, "address-2"},
+		{jan, mar, "address-1"},
+	}
+	for _, test := range tests {
+		item, err := tc.GetAsOf(test.at, test.knownAt)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+			continue
+		}
+		if item != test.expected {
+			t.Errorf("valid at %s known at %s: %v expected %s", test.at, test.knownAt, item, test.expected)
+		}
+	}
+	if item, _ := tc.Get(mar); item != "address-2" {
+		t.Errorf("current knowledge: %v expected address-2", item)
+	}
+	if _, err := tc.GetAsOf(jan, jan.Add(-time.Hour)); err == nil {
+		t.Error("expected error for unknown records")
+	}
+}
@@ -0,0 +1,160 @@
+// Package tracing instruments evently with OpenTelemetry. The trace context of a command
+// is persisted in the metadata of the events it causes, so the spans of consumers link
+// back to the command which produced the events. It's a module of its own, so importers
+// of evently don't depend on OpenTelemetry.
+package tracing
+
+import (
+	"context"
+
+	"github.com/openyard/evently/command"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/event"
+	"github.com/openyard/evently/query/consume"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of all spans
+const ScopeName = "github.com/openyard/evently"
+
+// attribute keys
+const (
+	CommandName     = attribute.Key("evently.command.name")
+	CommandID       = attribute.Key("evently.command.id")
+	AggregateID     = attribute.Key("evently.aggregate.id")
+	Stream          = attribute.Key("evently.stream")
+	ExpectedVersion = attribute.Key("evently.expected_version")
+	GlobalPos       = attribute.Key("evently.global_pos")
+	EventCount      = attribute.Key("evently.events")
+	Consumer        = attribute.Key("evently.consumer")
+)
+
+type Option func(t *Tracing)
+
+// Tracing creates spans and propagates their context through event metadata. It's the
+// command.Tracer of a command.Service, see command.WithTracer.
+type Tracing struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// New returns a Tracing using the global tracer provider and W3C trace context
+func New(opts ...Option) *Tracing {
+	t := &Tracing{propagator: propagation.TraceContext{}}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.tracer == nil {
+		t.tracer = otel.GetTracerProvider().Tracer(ScopeName)
+	}
+	return t
+}
+
+// WithTracerProvider sets the tracer provider, default is the global one
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(t *Tracing) {
+		t.tracer = tp.Tracer(ScopeName)
+	}
+}
+
+// WithPropagator sets the propagator writing the trace context to event metadata,
+// default is W3C trace context
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(t *Tracing) {
+		t.propagator = p
+	}
+}
+
+// Start starts a span with the given name as child of the span in ctx
+func (t *Tracing) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, name, opts...)
+}
+
+// StartProcess starts the span of processing the command as child of the span in ctx
+func (t *Tracing) StartProcess(ctx context.Context, cmd *command.Command) (context.Context, func(err error)) {
+	ctx, span := t.tracer.Start(ctx, "process "+cmd.CommandName(), trace.WithAttributes(
+		CommandName.String(cmd.CommandName()),
+		CommandID.String(cmd.CommandID()),
+		AggregateID.String(cmd.AggregateID())))
+	return ctx, func(err error) { End(span, err) }
+}
+
+// StartReadStream starts the client span of reading the stream
+func (t *Tracing) StartReadStream(ctx context.Context, stream string) func(events int, err error) {
+	_, span := t.tracer.Start(ctx, "ReadStream", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(Stream.String(stream)))
+	return func(events int, err error) {
+		span.SetAttributes(EventCount.Int(events))
+		End(span, err)
+	}
+}
+
+// StartAppendToStream starts the client span of appending events to the stream
+func (t *Tracing) StartAppendToStream(ctx context.Context, stream string, expectedVersion uint64, events int) func(err error) {
+	_, span := t.tracer.Start(ctx, "AppendToStream", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(Stream.String(stream),
+			ExpectedVersion.Int64(int64(expectedVersion)),
+			EventCount.Int(events)))
+	return func(err error) { End(span, err) }
+}
+
+// Inject returns copies of the events with the trace context of ctx added to their
+// metadata. Without a valid span in ctx the given events are returned.
+func (t *Tracing) Inject(ctx context.Context, events ...*event.Event) []*event.Event {
+	carrier := propagation.MapCarrier{}
+	t.propagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return events
+	}
+	traced := make([]*event.Event, len(events))
+	for n, e := range events {
+		traced[n] = e.Annotate(carrier)
+	}
+	return traced
+}
+
+// Extract returns ctx with the trace context persisted in the metadata of the event
+func (t *Tracing) Extract(ctx context.Context, e *event.Event) context.Context {
+	return t.propagator.Extract(ctx, propagation.MapCarrier(e.Metadata()))
+}
+
+// Consumer wraps the given consumer with a span per call linked to the spans which
+// produced the consumed events
+func (t *Tracing) Consumer(name string, consumer consume.Consumer) consume.Consumer {
+	return consume.ConsumerFunc(func(ctx *consume.Context, entries ...*es.Entry) error {
+		links := make([]trace.Link, 0, len(entries))
+		for _, entry := range entries {
+			sc := trace.SpanContextFromContext(t.Extract(context.Background(), entry.Event))
+			if sc.IsValid() {
+				links = append(links, trace.Link{SpanContext: sc, Attributes: []attribute.KeyValue{
+					Stream.String(entry.Stream), GlobalPos.Int64(int64(entry.GlobalPos))}})
+			}
+		}
+		attrs := []attribute.KeyValue{Consumer.String(name), EventCount.Int(len(entries))}
+		if len(entries) > 0 {
+			attrs = append(attrs, GlobalPos.Int64(int64(entries[0].GlobalPos)))
+		}
+		parent := context.Background()
+		if ctx != nil && ctx.Context != nil {
+			parent = ctx.Context
+		}
+		spanCtx, span := t.tracer.Start(parent, "consume "+name, trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithLinks(links...), trace.WithAttributes(attrs...))
+		err := consumer.Handle(&consume.Context{Context: spanCtx}, entries...)
+		End(span, err)
+		return err
+	})
+}
+
+// End records the error, if any, and ends the span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,90 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openyard/evently/command"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/command/es/estest"
+	"github.com/openyard/evently/example"
+	"github.com/openyard/evently/pkg/tracing"
+	"github.com/openyard/evently/query/consume"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tr := tracing.New(tracing.WithTracerProvider(tp))
+	store := estest.NewTestEventStore()
+	svc := command.NewService(store, new(example.CustomerFactory).Create, command.WithTracer(tr))
+
+	ctx, request := tp.Tracer("test").Start(context.Background(), "POST /customers")
+	birthdate, _ := time.Parse("2006-01-02", "1999-06-01")
+	if err := svc.ProcessContext(ctx, example.OnboardCustomer("4711", "John Doe", birthdate, 'M')); err != nil {
+		t.Fatal(err)
+	}
+	request.End()
+
+	entries, _ := store.ReadLog(0, 10)
+	if len(entries) != 1 || entries[0].Event.Metadata()["traceparent"] == "" {
+		t.Fatalf("expected trace context in event metadata, got %+v", entries)
+	}
+	consumer := tr.Consumer("projection", consume.ConsumerFunc(func(ctx *consume.Context, _ ...*es.Entry) error {
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			t.Error("expected span in consumer context")
+		}
+		return nil
+	}))
+	if err := consumer.Handle(&consume.Context{Context: context.Background()}, entries...); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, s := range exporter.GetSpans() {
+		spans[s.Name] = s
+	}
+	process, ok := spans["process customer/v1.onboardCustomer"]
+	if !ok || process.Parent.SpanID() != request.SpanContext().SpanID() {
+		t.Fatalf("expected process span as child of request, got %+v", spans)
+	}
+	for _, name := range []string{"ReadStream", "AppendToStream"} {
+		if s, ok := spans[name]; !ok || s.Parent.SpanID() != process.SpanContext.SpanID() {
+			t.Errorf("expected %s span as child of process span", name)
+		}
+	}
+	consumed := spans["consume projection"]
+	if consumed.SpanKind != trace.SpanKindConsumer || len(consumed.Links) != 1 ||
+		consumed.Links[0].SpanContext.SpanID() != process.SpanContext.SpanID() ||
+		consumed.Links[0].SpanContext.TraceID() != request.SpanContext().TraceID() {
+		t.Errorf("expected consumer span linked to process span, got %+v", consumed)
+	}
+}
+
+func TestTracing_error(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tr := tracing.New(tracing.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))))
+	svc := command.NewService(estest.NewTestEventStore(), new(example.CustomerFactory).Create, command.WithTracer(tr))
+	if err := svc.ProcessContext(context.Background(), command.New("unknown", "4711")); err == nil {
+		t.Fatal("expected unknown command to fail")
+	}
+	spans := exporter.GetSpans()
+	last := spans[len(spans)-1]
+	if last.Name != "process unknown" || last.Status.Code != codes.Error || len(last.Events) != 1 {
+		t.Errorf("expected failed process span with recorded error, got %+v", last)
+	}
+}
+
+var _ command.Tracer = (*tracing.Tracing)(nil)
+
+func TestTracing_Inject_noSpan(t *testing.T) {
+	changes, _ := new(example.CustomerFactory).Create().Execute(example.ActivateCustomer("4711"))
+	if traced := tracing.New().Inject(context.Background(), changes...); len(traced) != len(changes) {
+		t.Error("expected events unchanged without span")
+	}
+}
@@ -0,0 +1,166 @@
+// Package feed streams the entries of an es.Transport to browsers over Server-Sent Events
+// or WebSockets.
+//
+// Clients select entries with the query parameters stream, category and event, each of
+// them repeatable. An entry is delivered if it matches any value of every given parameter.
+// Every entry has its global position as event ID, so a reconnecting EventSource resumes
+// after the last received entry by its Last-Event-ID header. WebSocket clients pass the
+// global position as lastEventId query parameter. Without it, a feed starts at the head.
+//
+// It's a module of its own, so importers of evently don't depend on WebSocket.
+package feed
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
+)
+
+const defaultHeartbeat = 15 * time.Second
+
+type Option func(h *Handler)
+
+// CategoryFunc returns the category of an entry
+type CategoryFunc func(entry *es.Entry) string
+
+// Handler serves feeds of an es.Transport. Requests upgrading to a WebSocket get a
+// message per entry, all other requests get a stream of Server-Sent Events.
+type Handler struct {
+	transport es.Transport
+	heartbeat time.Duration
+	category  CategoryFunc
+	origins   []string
+	logger    *slog.Logger
+}
+
+// NewHandler returns a Handler serving feeds of the given transport
+func NewHandler(transport es.Transport, opts ...Option) *Handler {
+	h := &Handler{
+		transport: transport,
+		heartbeat: defaultHeartbeat,
+		category:  StreamCategory,
+		logger:    evently.Logger("feed"),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// WithHeartbeat sets the interval of heartbeats keeping idle connections open, default is 15s
+func WithHeartbeat(d time.Duration) Option {
+	return func(h *Handler) {
+		h.heartbeat = d
+	}
+}
+
+// WithCategory derives the category of an entry by the given func instead of StreamCategory
+func WithCategory(category CategoryFunc) Option {
+	return func(h *Handler) {
+		h.category = category
+	}
+}
+
+// WithOriginPatterns allows WebSocket connections from pages of other hosts matching the
+// given patterns, e.g. "*.example.com". By default, only the host of the request is allowed.
+func WithOriginPatterns(patterns ...string) Option {
+	return func(h *Handler) {
+		h.origins = append(h.origins, patterns...)
+	}
+}
+
+// WithLogger sets the logger, default is the evently logger of component "feed"
+func WithLogger(logger *slog.Logger) Option {
+	return func(h *Handler) {
+		h.logger = logger
+	}
+}
+
+// StreamCategory returns the part of the stream name before the first dash, e.g.
+// "customer" for the stream "customer-42"
+func StreamCategory(entry *es.Entry) string {
+	category, _, _ := strings.Cut(entry.Stream, "-")
+	return category
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sub, err := h.subscribe(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer sub.Close()
+	f := newFilter(r, h.category)
+	if isWebSocket(r) {
+		h.serveWebSocket(w, r, sub, f)
+		return
+	}
+	h.serveEvents(w, r, sub, f)
+}
+
+// subscribe subscribes the transport after the last event ID of the request or at the head
+func (h *Handler) subscribe(r *http.Request) (es.Subscription, error) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	if lastEventID == "" {
+		return h.transport.Subscribe(), nil
+	}
+	pos, err := strconv.ParseUint(lastEventID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return h.transport.SubscribeWithOffset(pos + 1), nil
+}
+
+// filter selects the entries requested by the query parameters
+type filter struct {
+	streams    map[string]bool
+	categories map[string]bool
+	events     map[string]bool
+	category   CategoryFunc
+}
+
+func newFilter(r *http.Request, category CategoryFunc) *filter {
+	query := r.URL.Query()
+	return &filter{
+		streams:    set(query["stream"]),
+		categories: set(query["category"]),
+		events:     set(query["event"]),
+		category:   category,
+	}
+}
+
+func (f *filter) match(entry *es.Entry) bool {
+	return (f.streams == nil || f.streams[entry.Stream]) &&
+		(f.categories == nil || f.categories[f.category(entry)]) &&
+		(f.events == nil || f.events[entry.Event.Name()])
+}
+
+func set(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	s := make(map[string]bool, len(values))
+	for _, v := range values {
+		s[v] = true
+	}
+	return s
+}
+
+func (h *Handler) logFailure(sub es.Subscription) {
+	if err := sub.Err(); err != nil {
+		h.logger.Error("subscription failed", evently.Err(err))
+	}
+}
@@ -0,0 +1,114 @@
+package feed_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/command/es/estest"
+	"github.com/openyard/evently/event"
+	"github.com/openyard/evently/query/feed"
+)
+
+func TestHandler_events(t *testing.T) {
+	store := estest.NewTestEventStore()
+	_ = store.AppendToStream("customer-1", 0, event.NewDomainEvent("created", "1"), event.NewDomainEvent("activated", "1"))
+	_ = store.AppendToStream("order-1", 0, event.NewDomainEvent("created", "1"))
+	server := httptest.NewServer(feed.NewHandler(store))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"?category=customer&event=activated&event=blocked", nil)
+	req.Header.Set("Last-Event-ID", "0")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", res.Header.Get("Content-Type"))
+	}
+	_ = store.AppendToStream("customer-1", 2, event.NewDomainEvent("blocked", "1"))
+
+	lines := readLines(t, bufio.NewScanner(res.Body), 8)
+	expected := []string{"id: 1", "event: activated", "data: ", "", "id: 3", "event: blocked", "data: ", ""}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, expected[i]) {
+			t.Errorf("line %d: expected %q, got %q", i, expected[i], line)
+		}
+	}
+}
+
+func TestHandler_heartbeat(t *testing.T) {
+	server := httptest.NewServer(feed.NewHandler(estest.NewTestEventStore(), feed.WithHeartbeat(10*time.Millisecond)))
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if line := readLines(t, bufio.NewScanner(res.Body), 1)[0]; line != ": heartbeat" {
+		t.Errorf("expected heartbeat, got %q", line)
+	}
+}
+
+func TestHandler_webSocket(t *testing.T) {
+	store := estest.NewTestEventStore()
+	_ = store.AppendToStream("customer-1", 0, event.NewDomainEvent("created", "1"))
+	server := httptest.NewServer(feed.NewHandler(store))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http")+"?stream=customer-2&lastEventId=0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.CloseNow()
+	_ = store.AppendToStream("customer-1", 1, event.NewDomainEvent("activated", "1"))
+	_ = store.AppendToStream("customer-2", 0, event.NewDomainEvent("created", "2"))
+
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry es.Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.GlobalPos != 2 || entry.Stream != "customer-2" || entry.Event.Name() != "created" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
+
+func readLines(t *testing.T, scanner *bufio.Scanner, n int) []string {
+	t.Helper()
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	var read []string
+	timeout := time.After(time.Second)
+	for len(read) < n {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatalf("feed ended after %d lines", len(read))
+			}
+			read = append(read, line)
+		case <-timeout:
+			t.Fatalf("read %d of %d lines", len(read), n)
+		}
+	}
+	return read
+}
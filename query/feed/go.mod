@@ -0,0 +1,10 @@
+module github.com/openyard/evently/query/feed
+
+go 1.21
+
+require (
+	github.com/coder/websocket v1.8.13
+	github.com/openyard/evently v0.0.0
+)
+
+replace github.com/openyard/evently => ../..
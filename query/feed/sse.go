@@ -0,0 +1,57 @@
+package feed
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/openyard/evently/command/es"
+)
+
+// serveEvents streams the entries as Server-Sent Events named like the event until the
+// client disconnects. A failing subscription ends the response, the client reconnects.
+func (h *Handler) serveEvents(w http.ResponseWriter, r *http.Request, sub es.Subscription, f *filter) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case entries, ok := <-sub.Entries():
+			if !ok {
+				h.logFailure(sub)
+				return
+			}
+			for _, entry := range entries {
+				if !f.match(entry) {
+					continue
+				}
+				data, err := json.Marshal(entry)
+				if err != nil {
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", entry.GlobalPos, entry.Event.Name(), data); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
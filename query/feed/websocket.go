@@ -0,0 +1,62 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/openyard/evently/command/es"
+)
+
+// serveWebSocket sends a text message per entry until the client disconnects. Heartbeats
+// are pings, a failing subscription closes the connection with an internal error.
+func (h *Handler) serveWebSocket(w http.ResponseWriter, r *http.Request, sub es.Subscription, f *filter) {
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: h.origins})
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+	ctx := conn.CloseRead(r.Context())
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case entries, ok := <-sub.Entries():
+			if !ok {
+				h.logFailure(sub)
+				_ = conn.Close(websocket.StatusInternalError, "subscription failed")
+				return
+			}
+			for _, entry := range entries {
+				if !f.match(entry) {
+					continue
+				}
+				data, err := json.Marshal(entry)
+				if err != nil {
+					continue
+				}
+				if err := conn.Write(ctx, websocket.MessageText, data); err != nil {
+					return
+				}
+			}
+		case <-heartbeat.C:
+			pingCtx, cancel := context.WithTimeout(ctx, h.heartbeat)
+			err := conn.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				return
+			}
+		case <-ctx.Done():
+			_ = conn.Close(websocket.StatusNormalClosure, "")
+			return
+		}
+	}
+}
+
+func isWebSocket(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
@@ -0,0 +1,6 @@
+package inbox
+
+// error codes
+const (
+	ErrStore = iota + 9901
+)
@@ -0,0 +1,161 @@
+// Package inbox makes consumers idempotent. Subscriptions and brokers deliver at least
+// once, so a consumer sees events again after a restart. An Inbox records the IDs of the
+// events a consumer processed in a Store and skips them on redelivery.
+package inbox
+
+import (
+	"sync"
+	"time"
+
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/event"
+	"github.com/openyard/evently/query/consume"
+)
+
+const (
+	defaultRetention      = 7 * 24 * time.Hour
+	defaultExpiryInterval = time.Hour
+)
+
+// Store records the events processed by consumers
+type Store interface {
+	// Processed returns the IDs of the given events the consumer already processed
+	Processed(consumer string, eventIDs ...string) (map[string]bool, error)
+	// MarkProcessed records the events as processed by the consumer at the given time
+	MarkProcessed(consumer string, at time.Time, eventIDs ...string) error
+	// Expire removes all records of the consumer processed before the given time and
+	// returns their number
+	Expire(consumer string, before time.Time) (int, error)
+}
+
+type Option func(i *Inbox)
+
+// Inbox of a consumer. Records are kept for the retention window, an event redelivered
+// later is processed again.
+type Inbox struct {
+	sync.Mutex
+	consumer       string
+	store          Store
+	retention      time.Duration
+	expiryInterval time.Duration
+	expiredAt      time.Time
+}
+
+// NewInbox returns the Inbox of the named consumer. The name must be unique among all
+// consumers sharing the store.
+func NewInbox(consumer string, store Store, opts ...Option) *Inbox {
+	i := &Inbox{
+		consumer:       consumer,
+		store:          store,
+		retention:      defaultRetention,
+		expiryInterval: defaultExpiryInterval,
+		expiredAt:      time.Now(),
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// WithRetention sets the time records are kept, default is 7 days
+func WithRetention(d time.Duration) Option {
+	return func(i *Inbox) {
+		i.retention = d
+	}
+}
+
+// WithExpiryInterval sets how often expired records are removed while consuming, default is 1h
+func WithExpiryInterval(d time.Duration) Option {
+	return func(i *Inbox) {
+		i.expiryInterval = d
+	}
+}
+
+// Consumer wraps the given consumer, which gets only events not processed before
+func (i *Inbox) Consumer(consumer consume.Consumer) consume.Consumer {
+	return consume.ConsumerFunc(func(ctx *consume.Context, entries ...*es.Entry) error {
+		return i.process(entries2events(entries), func(unprocessed map[string]bool) error {
+			pending := make([]*es.Entry, 0, len(entries))
+			for _, entry := range entries {
+				if unprocessed[entry.Event.ID()] {
+					pending = append(pending, entry)
+				}
+			}
+			return consumer.Handle(ctx, pending...)
+		})
+	})
+}
+
+// HandleFunc wraps the given handler, which gets only events not processed before
+func (i *Inbox) HandleFunc(handler event.HandleFunc) event.HandleFunc {
+	return func(events ...*event.Event) error {
+		return i.process(events, func(unprocessed map[string]bool) error {
+			pending := make([]*event.Event, 0, len(events))
+			for _, e := range events {
+				if unprocessed[e.ID()] {
+					pending = append(pending, e)
+				}
+			}
+			return handler(pending...)
+		})
+	}
+}
+
+// Expire removes all records older than the retention window
+func (i *Inbox) Expire() (int, error) {
+	i.Lock()
+	defer i.Unlock()
+	return i.expire(time.Now())
+}
+
+// process calls handle with the IDs of all unprocessed events and marks them processed
+// if handle succeeds. Events are processed one batch after the other.
+func (i *Inbox) process(events []*event.Event, handle func(unprocessed map[string]bool) error) error {
+	i.Lock()
+	defer i.Unlock()
+	now := time.Now()
+	if now.Sub(i.expiredAt) >= i.expiryInterval {
+		if _, err := i.expire(now); err != nil {
+			return err
+		}
+	}
+	ids := make([]string, len(events))
+	for n, e := range events {
+		ids[n] = e.ID()
+	}
+	processed, err := i.store.Processed(i.consumer, ids...)
+	if err != nil {
+		return err
+	}
+	unprocessed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if !processed[id] {
+			unprocessed[id] = true
+		}
+	}
+	if len(unprocessed) == 0 {
+		return nil
+	}
+	if err := handle(unprocessed); err != nil {
+		return err
+	}
+	pending := make([]string, 0, len(unprocessed))
+	for id := range unprocessed {
+		pending = append(pending, id)
+	}
+	return i.store.MarkProcessed(i.consumer, now, pending...)
+}
+
+// expire removes expired records. Must be called locked.
+func (i *Inbox) expire(now time.Time) (int, error) {
+	i.expiredAt = now
+	return i.store.Expire(i.consumer, now.Add(-i.retention))
+}
+
+func entries2events(entries []*es.Entry) []*event.Event {
+	events := make([]*event.Event, len(entries))
+	for n, entry := range entries {
+		events[n] = entry.Event
+	}
+	return events
+}
@@ -0,0 +1,93 @@
+package inbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/openyard/evently/command/es"
+	"github.com/openyard/evently/event"
+	"github.com/openyard/evently/query/consume"
+	"github.com/openyard/evently/query/inbox"
+	"github.com/openyard/evently/query/inbox/inboxtest"
+)
+
+func TestInbox_Consumer(t *testing.T) {
+	i := inbox.NewInbox("projection", inbox.NewMemoryStore())
+	var consumed []*es.Entry
+	fail := true
+	c := i.Consumer(consume.ConsumerFunc(func(_ *consume.Context, entries ...*es.Entry) error {
+		if fail {
+			fail = false
+			return errors.New("consumer failed")
+		}
+		consumed = append(consumed, entries...)
+		return nil
+	}))
+	ctx := &consume.Context{Context: context.Background()}
+	e1, e2 := event.NewIntegrationEvent("customer-created", "1"), event.NewIntegrationEvent("customer-blocked", "1")
+	entries := []*es.Entry{{GlobalPos: 0, Stream: "customer-1", Version: 1, Event: e1}}
+	if err := c.Handle(ctx, entries...); err == nil {
+		t.Fatal("expected error of consumer")
+	}
+	if err := c.Handle(ctx, entries...); err != nil { // redelivered after failure
+		t.Fatal(err)
+	}
+	entries = append(entries, &es.Entry{GlobalPos: 1, Stream: "customer-1", Version: 2, Event: e2})
+	if err := c.Handle(ctx, entries...); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Handle(ctx, entries...); err != nil {
+		t.Fatal(err)
+	}
+	if len(consumed) != 2 || consumed[0].Event != e1 || consumed[1].Event != e2 {
+		t.Errorf("expected each event consumed once, got %+v", consumed)
+	}
+}
+
+func TestInbox_HandleFunc(t *testing.T) {
+	store := inbox.NewMemoryStore()
+	var handled []*event.Event
+	handler := func(events ...*event.Event) error {
+		handled = append(handled, events...)
+		return nil
+	}
+	e := event.NewIntegrationEvent("customer-created", "1")
+	_ = inbox.NewInbox("mailer", store).HandleFunc(handler)(e)
+	_ = inbox.NewInbox("mailer", store).HandleFunc(handler)(e)
+	_ = inbox.NewInbox("auditor", store).HandleFunc(handler)(e)
+	if len(handled) != 2 {
+		t.Errorf("expected event handled once per consumer, got %d", len(handled))
+	}
+}
+
+func TestInbox_Expire(t *testing.T) {
+	store := inbox.NewMemoryStore()
+	var handled int
+	handler := func(events ...*event.Event) error {
+		handled += len(events)
+		return nil
+	}
+	e := event.NewIntegrationEvent("customer-created", "1")
+	i := inbox.NewInbox("mailer", store, inbox.WithRetention(10*time.Millisecond), inbox.WithExpiryInterval(time.Hour))
+	_ = i.HandleFunc(handler)(e)
+	time.Sleep(20 * time.Millisecond)
+	if n, err := i.Expire(); err != nil || n != 1 {
+		t.Fatalf("expected 1 record expired, got %d (%v)", n, err)
+	}
+	_ = i.HandleFunc(handler)(e)
+	if handled != 2 {
+		t.Errorf("expected expired event handled again, got %d", handled)
+	}
+}
+
+func TestStores(t *testing.T) {
+	dir := t.TempDir()
+	t.Run("memory", func(t *testing.T) {
+		inboxtest.TestStore(t, inbox.NewMemoryStore(), nil)
+	})
+	t.Run("file", func(t *testing.T) {
+		inboxtest.TestStore(t, inbox.NewFileStore(dir), func() inbox.Store { return inbox.NewFileStore(dir) })
+	})
+}
@@ -0,0 +1,47 @@
+// Package inboxtest provides the behavioural tests every inbox.Store must pass
+package inboxtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openyard/evently/query/inbox"
+)
+
+// TestStore tests the given empty store. If reopen isn't nil, the records are read back
+// through the store it returns to test they are persisted.
+func TestStore(t *testing.T, store inbox.Store, reopen func() inbox.Store) {
+	now := time.Now()
+	if err := store.MarkProcessed("c1", now.Add(-time.Hour), "e1", "e2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MarkProcessed("c1", now, "e3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MarkProcessed("c2", now.Add(-time.Hour), "e1"); err != nil {
+		t.Fatal(err)
+	}
+	if reopen != nil {
+		store = reopen()
+	}
+	processed, err := store.Processed("c1", "e1", "e3", "e4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(processed) != 2 || !processed["e1"] || !processed["e3"] {
+		t.Errorf("unexpected processed events %v", processed)
+	}
+	if n, err := store.Expire("c1", now.Add(-time.Minute)); err != nil || n != 2 {
+		t.Fatalf("expected 2 records expired, got %d (%v)", n, err)
+	}
+	if reopen != nil {
+		store = reopen()
+	}
+	processed, _ = store.Processed("c1", "e1", "e2", "e3")
+	if len(processed) != 1 || !processed["e3"] {
+		t.Errorf("unexpected processed events after expiry %v", processed)
+	}
+	if processed, _ = store.Processed("c2", "e1"); !processed["e1"] {
+		t.Error("expected records of other consumers kept")
+	}
+}
@@ -0,0 +1,128 @@
+package inbox
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openyard/evently"
+)
+
+var _ Store = (*SQLStore)(nil)
+
+const defaultTable = "inbox"
+
+type SQLOption func(s *SQLStore)
+
+// SQLStore keeps the records in a table of a SQL database with the columns consumer,
+// event_id and processed_at (unix nanoseconds)
+type SQLStore struct {
+	db          *sql.DB
+	table       string
+	placeholder func(n int) string
+}
+
+// NewSQLStore returns a SQLStore using the given database. The table is created by
+// CreateTable.
+func NewSQLStore(db *sql.DB, opts ...SQLOption) *SQLStore {
+	s := &SQLStore{db: db, table: defaultTable, placeholder: func(int) string { return "?" }}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithTable sets the name of the table, default is "inbox"
+func WithTable(table string) SQLOption {
+	return func(s *SQLStore) {
+		s.table = table
+	}
+}
+
+// WithDollarPlaceholders uses $1, $2, ... as query placeholders, e.g. for PostgreSQL.
+// Default is ?.
+func WithDollarPlaceholders() SQLOption {
+	return func(s *SQLStore) {
+		s.placeholder = func(n int) string { return fmt.Sprintf("$%d", n) }
+	}
+}
+
+// CreateTable creates the table unless it exists
+func (s *SQLStore) CreateTable() error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	consumer VARCHAR(255) NOT NULL,
+	event_id VARCHAR(255) NOT NULL,
+	processed_at BIGINT NOT NULL,
+	PRIMARY KEY (consumer, event_id))`, s.table)
+	if _, err := s.db.Exec(query); err != nil {
+		return evently.Errorf(ErrStore, "ErrStore", "create table %s", s.table).CausedBy(err)
+	}
+	return nil
+}
+
+func (s *SQLStore) Processed(consumer string, eventIDs ...string) (map[string]bool, error) {
+	result := make(map[string]bool)
+	if len(eventIDs) == 0 {
+		return result, nil
+	}
+	args := make([]any, 0, len(eventIDs)+1)
+	args = append(args, consumer)
+	in := make([]string, len(eventIDs))
+	for n, id := range eventIDs {
+		args = append(args, id)
+		in[n] = s.placeholder(n + 2)
+	}
+	query := fmt.Sprintf("SELECT event_id FROM %s WHERE consumer = %s AND event_id IN (%s)",
+		s.table, s.placeholder(1), strings.Join(in, ", "))
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, evently.Errorf(ErrStore, "ErrStore", "read records of %q", consumer).CausedBy(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, evently.Errorf(ErrStore, "ErrStore", "read records of %q", consumer).CausedBy(err)
+		}
+		result[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, evently.Errorf(ErrStore, "ErrStore", "read records of %q", consumer).CausedBy(err)
+	}
+	return result, nil
+}
+
+// MarkProcessed inserts the records in one transaction
+func (s *SQLStore) MarkProcessed(consumer string, at time.Time, eventIDs ...string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return evently.Errorf(ErrStore, "ErrStore", "write records of %q", consumer).CausedBy(err)
+	}
+	defer tx.Rollback()
+	query := fmt.Sprintf("INSERT INTO %s (consumer, event_id, processed_at) VALUES (%s, %s, %s)",
+		s.table, s.placeholder(1), s.placeholder(2), s.placeholder(3))
+	for _, id := range eventIDs {
+		if _, err := tx.Exec(query, consumer, id, at.UnixNano()); err != nil {
+			return evently.Errorf(ErrStore, "ErrStore", "write records of %q", consumer).CausedBy(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return evently.Errorf(ErrStore, "ErrStore", "write records of %q", consumer).CausedBy(err)
+	}
+	return nil
+}
+
+func (s *SQLStore) Expire(consumer string, before time.Time) (int, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE consumer = %s AND processed_at < %s",
+		s.table, s.placeholder(1), s.placeholder(2))
+	res, err := s.db.Exec(query, consumer, before.UnixNano())
+	if err != nil {
+		return 0, evently.Errorf(ErrStore, "ErrStore", "expire records of %q", consumer).CausedBy(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, evently.Errorf(ErrStore, "ErrStore", "expire records of %q", consumer).CausedBy(err)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,3 @@
+// Package sqlitetest tests the inbox.SQLStore on SQLite. It's a module of its own, so the
+// SQLite driver isn't a dependency of evently.
+package sqlitetest
@@ -0,0 +1,26 @@
+package sqlitetest_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/openyard/evently/query/inbox"
+	"github.com/openyard/evently/query/inbox/inboxtest"
+	_ "modernc.org/sqlite"
+)
+
+func TestSQLStore(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "inbox.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	store := inbox.NewSQLStore(db, inbox.WithTable("processed_events"))
+	if err := store.CreateTable(); err != nil {
+		t.Fatal(err)
+	}
+	inboxtest.TestStore(t, store, func() inbox.Store {
+		return inbox.NewSQLStore(db, inbox.WithTable("processed_events"))
+	})
+}
@@ -0,0 +1,200 @@
+package inbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/openyard/evently"
+)
+
+var _ Store = (*MemoryStore)(nil)
+var _ Store = (*FileStore)(nil)
+
+// MemoryStore keeps the records in memory
+type MemoryStore struct {
+	sync.RWMutex
+	records map[string]map[string]time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]map[string]time.Time)}
+}
+
+func (s *MemoryStore) Processed(consumer string, eventIDs ...string) (map[string]bool, error) {
+	s.RLock()
+	defer s.RUnlock()
+	return processed(s.records[consumer], eventIDs), nil
+}
+
+func (s *MemoryStore) MarkProcessed(consumer string, at time.Time, eventIDs ...string) error {
+	s.Lock()
+	defer s.Unlock()
+	records, ok := s.records[consumer]
+	if !ok {
+		records = make(map[string]time.Time)
+		s.records[consumer] = records
+	}
+	for _, id := range eventIDs {
+		records[id] = at
+	}
+	return nil
+}
+
+func (s *MemoryStore) Expire(consumer string, before time.Time) (int, error) {
+	s.Lock()
+	defer s.Unlock()
+	return expire(s.records[consumer], before), nil
+}
+
+// FileStore keeps the records of each consumer as JSON Lines file in a directory. The
+// records of a consumer are read into memory on first use.
+type FileStore struct {
+	sync.Mutex
+	dir     string
+	records map[string]map[string]time.Time
+}
+
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir, records: make(map[string]map[string]time.Time)}
+}
+
+type record struct {
+	ID string
+	At time.Time
+}
+
+func (s *FileStore) Processed(consumer string, eventIDs ...string) (map[string]bool, error) {
+	s.Lock()
+	defer s.Unlock()
+	records, err := s.load(consumer)
+	if err != nil {
+		return nil, err
+	}
+	return processed(records, eventIDs), nil
+}
+
+// MarkProcessed appends the records to the file of the consumer and syncs it
+func (s *FileStore) MarkProcessed(consumer string, at time.Time, eventIDs ...string) error {
+	s.Lock()
+	defer s.Unlock()
+	records, err := s.load(consumer)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path(consumer), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return evently.Errorf(ErrStore, "ErrStore", "open records of %q", consumer).CausedBy(err)
+	}
+	enc := json.NewEncoder(f)
+	for _, id := range eventIDs {
+		if err := enc.Encode(&record{ID: id, At: at}); err != nil {
+			_ = f.Close()
+			return evently.Errorf(ErrStore, "ErrStore", "write records of %q", consumer).CausedBy(err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return evently.Errorf(ErrStore, "ErrStore", "sync records of %q", consumer).CausedBy(err)
+	}
+	if err := f.Close(); err != nil {
+		return evently.Errorf(ErrStore, "ErrStore", "close records of %q", consumer).CausedBy(err)
+	}
+	for _, id := range eventIDs {
+		records[id] = at
+	}
+	return nil
+}
+
+// Expire rewrites the file of the consumer atomically without the expired records
+func (s *FileStore) Expire(consumer string, before time.Time) (int, error) {
+	s.Lock()
+	defer s.Unlock()
+	records, err := s.load(consumer)
+	if err != nil {
+		return 0, err
+	}
+	expired := expire(records, before)
+	if expired == 0 {
+		return 0, nil
+	}
+	tmp, err := os.CreateTemp(s.dir, "."+url.PathEscape(consumer)+"-*")
+	if err != nil {
+		return 0, evently.Errorf(ErrStore, "ErrStore", "expire records of %q", consumer).CausedBy(err)
+	}
+	defer os.Remove(tmp.Name())
+	enc := json.NewEncoder(tmp)
+	for id, at := range records {
+		if err := enc.Encode(&record{ID: id, At: at}); err != nil {
+			_ = tmp.Close()
+			return 0, evently.Errorf(ErrStore, "ErrStore", "expire records of %q", consumer).CausedBy(err)
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, evently.Errorf(ErrStore, "ErrStore", "expire records of %q", consumer).CausedBy(err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(consumer)); err != nil {
+		return 0, evently.Errorf(ErrStore, "ErrStore", "expire records of %q", consumer).CausedBy(err)
+	}
+	return expired, nil
+}
+
+// load returns the records of the consumer and reads them from its file on first use.
+// Must be called locked.
+func (s *FileStore) load(consumer string) (map[string]time.Time, error) {
+	if records, ok := s.records[consumer]; ok {
+		return records, nil
+	}
+	records := make(map[string]time.Time)
+	f, err := os.Open(s.path(consumer))
+	if os.IsNotExist(err) {
+		s.records[consumer] = records
+		return records, nil
+	}
+	if err != nil {
+		return nil, evently.Errorf(ErrStore, "ErrStore", "read records of %q", consumer).CausedBy(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue // torn write of a crash
+		}
+		records[r.ID] = r.At
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, evently.Errorf(ErrStore, "ErrStore", "read records of %q", consumer).CausedBy(err)
+	}
+	s.records[consumer] = records
+	return records, nil
+}
+
+func (s *FileStore) path(consumer string) string {
+	return filepath.Join(s.dir, url.PathEscape(consumer)+".jsonl")
+}
+
+func processed(records map[string]time.Time, eventIDs []string) map[string]bool {
+	result := make(map[string]bool)
+	for _, id := range eventIDs {
+		if _, ok := records[id]; ok {
+			result[id] = true
+		}
+	}
+	return result
+}
+
+func expire(records map[string]time.Time, before time.Time) int {
+	var expired int
+	for id, at := range records {
+		if at.Before(before) {
+			delete(records, id)
+			expired++
+		}
+	}
+	return expired
+}
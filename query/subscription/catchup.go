@@ -2,14 +2,17 @@ package subscription
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/openyard/evently"
 	"github.com/openyard/evently/command/es"
 	"github.com/openyard/evently/query/consume"
 )
 
+const defaultRetryInterval = time.Second
+
 type CatchUpOption func(s *CatchUpSubscription)
 
 // CatchUpSubscription is a subscription.CatchUpSubscription listening
@@ -19,65 +22,146 @@ type CatchUpSubscription struct {
 	context context.Context
 	cancel  context.CancelFunc
 
-	entries    <-chan []*es.Entry
-	checkpoint *Checkpoint
+	subscription  es.Subscription
+	checkpoint    *Checkpoint
+	retryInterval time.Duration
 
 	transport es.Transport
 	consume   consume.ConsumerFunc
 	ack       AckFunc
 	nack      NackFunc
+	logger    *slog.Logger
 
-	listening bool
+	resubscribeOnError bool
+	listening          bool
 }
 
 func NewCatchUpSubscription(transport es.Transport, opts ...CatchUpOption) *CatchUpSubscription {
 	s := &CatchUpSubscription{
-		transport: transport,
-		consume:   consume.DefaultConsumer.Handle,
-		ack:       noopAck,
-		nack:      noopNack,
+		transport:     transport,
+		consume:       consume.DefaultConsumer.Handle,
+		ack:           noopAck,
+		nack:          noopNack,
+		retryInterval: defaultRetryInterval,
+		logger:        evently.Logger("subscription"),
 	}
 	for _, opt := range opts {
 		opt(s)
 	}
+	if s.checkpoint != nil {
+		s.logger = s.logger.With(evently.LogSubscription, s.checkpoint.ID())
+	}
 	return s
 }
 
-// Listen starts to listen for new events from the transport
+// Listen starts to listen for new events from the transport. If the subscription of
+// the transport fails, Listen subscribes again from the checkpoint after the retry interval.
+// A batch the consumer fails on is nacked and listening goes on with the next batch,
+// unless WithResubscribeOnError is set.
 func (s *CatchUpSubscription) Listen() {
 	s.Lock()
 	defer s.Unlock()
 	if s.listening {
-		log.Printf("[%T] %s already listening - ignore", s, s.checkpoint.ID())
+		s.logger.Warn("already listening - ignore")
 		return
 	}
-	go func(s *CatchUpSubscription) {
-		evently.DEBUG("[%T] [DEBUG] start listening...", s)
-		for {
-			s.entries = s.transport.SubscribeWithOffset(s.checkpoint.GlobalPosition())
-			s.Lock()
-			s.context, s.cancel = context.WithCancel(context.Background())
-			s.Unlock()
-			select {
-			case entries := <-s.entries:
-				if err := s.consume(&consume.Context{Context: s.context}, entries...); err != nil {
-					log.Printf("[%T] [ERROR] couldn't handle all events: %s\n%v", s, err, entries)
-					s.nack(entries...)
+	s.context, s.cancel = context.WithCancel(context.Background())
+	s.listening = true
+	go s.listen(s.context)
+}
+
+func (s *CatchUpSubscription) listen(ctx context.Context) {
+	s.logger.Debug("start listening")
+	for {
+		sub := s.transport.SubscribeWithOffset(s.checkpoint.GlobalPosition())
+		s.Lock()
+		s.subscription = sub
+		s.Unlock()
+		err := s.receive(ctx, sub)
+		sub.Close()
+		if err == nil {
+			s.logger.Debug("stop listening", "cause", ctx.Err())
+			return
+		}
+		s.logger.Error("subscription failed", "retryIn", s.retryInterval, evently.Err(err))
+		select {
+		case <-time.After(s.retryInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// receive consumes the entries of the subscription until it ends or the context is done
+func (s *CatchUpSubscription) receive(ctx context.Context, sub es.Subscription) error {
+	for {
+		select {
+		case entries, ok := <-sub.Entries():
+			if !ok {
+				return sub.Err()
+			}
+			if err := s.consume(&consume.Context{Context: ctx}, entries...); err != nil {
+				s.logger.Error("couldn't handle all events", evently.LogGlobalPos, entries[0].GlobalPos,
+					"entries", len(entries), evently.Err(err))
+				s.nack(entries...)
+				if s.resubscribeOnError {
+					return err
 				}
-				evently.DEBUG("[%T] [DEBUG] ack all <%d> events: n%+v", s, len(entries), entries)
-				s.ack(entries...)
-			case <-s.context.Done():
-				evently.DEBUG("[%T] [DEBUG] context done <%v>", s, s.context.Err())
-				return
+				continue
 			}
+			s.logger.Debug("ack all events", evently.LogGlobalPos, entries[0].GlobalPos, "entries", len(entries))
+			s.ack(entries...)
+		case <-ctx.Done():
+			return nil
 		}
-	}(s)
+	}
 }
 
+// Phase returns whether the subscription is catching up or live
+func (s *CatchUpSubscription) Phase() es.Phase {
+	s.RLock()
+	defer s.RUnlock()
+	if s.subscription == nil {
+		return es.CatchUp
+	}
+	return s.subscription.Phase()
+}
+
+// Stop stops to listen and closes the subscription of the transport
 func (s *CatchUpSubscription) Stop() {
 	s.Lock()
 	defer s.Unlock()
+	if !s.listening {
+		return
+	}
 	s.cancel()
+	if s.subscription != nil {
+		s.subscription.Close()
+	}
+	s.listening = false
+}
+
+// WithRetryInterval sets the time to wait before subscribing again after the subscription
+// of the transport failed. The default is 1s.
+func WithRetryInterval(d time.Duration) CatchUpOption {
+	return func(s *CatchUpSubscription) {
+		s.retryInterval = d
+	}
+}
+
+// WithResubscribeOnError subscribes again from the checkpoint after the retry interval
+// if the consumer fails on a batch, so no later batch is consumed before the failed one
+func WithResubscribeOnError() CatchUpOption {
+	return func(s *CatchUpSubscription) {
+		s.resubscribeOnError = true
+	}
+}
+
+// WithLogger sets the logger, default is the evently logger of component "subscription"
+func WithLogger(logger *slog.Logger) CatchUpOption {
+	return func(s *CatchUpSubscription) {
+		s.logger = logger
+	}
 }
 
 // WithCheckpoint sets the given checkpoint to the CatchUpSubscription
@@ -101,7 +185,8 @@ func WithAckFunc(ackFunc AckFunc) CatchUpOption {
 	}
 }
 
-// WithNackFunc uses the given nackFunc for the CatchUpSubscription if consumer result wasn't successful
+// WithNackFunc uses the given nackFunc for the CatchUpSubscription if consumer result wasn't successful.
+// Nacked entries aren't acked.
 func WithNackFunc(nackFunc NackFunc) CatchUpOption {
 	return func(s *CatchUpSubscription) {
 		s.nack = nackFunc
@@ -1,6 +1,7 @@
 package subscription_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -50,6 +51,11 @@ func TestCatchUpSubscription_Listen(t *testing.T) {
 			t.Logf("[TestCatchUpSubscription_Listen] %s", s)
 		}
 
+		// the checkpoint is updated by the ack after the handlers returned
+		deadline := time.Now().Add(time.Second)
+		for testCheckpoint.GlobalPosition() != 129 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
 		t.Logf("[TestCatchUpSubscription_Listen] new globalPos = %d", testCheckpoint.GlobalPosition())
 
 		if 129 != testCheckpoint.GlobalPosition() {
@@ -74,3 +80,38 @@ func setup(_es es.EventStore, j int) {
 		_ = _es.AppendToStream("test-stream-0", uint64(i), event.NewDomainEvent("test-event", "0"))
 	}
 }
+
+func TestCatchUpSubscription_Listen_resubscribe(t *testing.T) {
+	store := estest.NewTestEventStore()
+	setup(store, 20)
+	faulty := estest.NewFaultyEventStore(store, 1, estest.InjectError(estest.OpDeliver, errors.New("connection lost"), 0.5))
+	checkpoint := subscription.NewCheckpoint("resubscribe", 0, time.Now())
+	s := subscription.NewCatchUpSubscription(faulty,
+		subscription.WithCheckpoint(checkpoint),
+		subscription.WithRetryInterval(time.Millisecond),
+		subscription.WithConsumer(consume.ConsumerFunc(func(_ *consume.Context, _ ...*es.Entry) error { return nil })),
+		subscription.WithAckFunc(func(entries ...*es.Entry) {
+			checkpoint.Update(checkpoint.MaxGlobalPos(entries...))
+		}))
+	s.Listen()
+	defer s.Stop()
+	awaitCheckpoint(checkpoint, 20)
+	for i := 20; i < 30; i++ {
+		_ = store.AppendToStream("test-stream-0", uint64(i), event.NewDomainEvent("test-event", "0"))
+		awaitCheckpoint(checkpoint, uint64(i+1))
+	}
+
+	if checkpoint.GlobalPosition() != 30 {
+		t.Errorf("expected checkpoint@30, but is @%d", checkpoint.GlobalPosition())
+	}
+	if faulty.Injected(estest.FaultError) == 0 {
+		t.Error("no subscription failed")
+	}
+}
+
+func awaitCheckpoint(checkpoint *subscription.Checkpoint, pos uint64) {
+	deadline := time.Now().Add(time.Second)
+	for checkpoint.GlobalPosition() < pos && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -1,12 +1,15 @@
 package subscription
 
 import (
-	"github.com/openyard/evently/command/es"
-	"log"
 	"sync/atomic"
 	"time"
+
+	"github.com/openyard/evently"
+	"github.com/openyard/evently/command/es"
 )
 
+var logger = evently.Logger("subscription")
+
 type CheckpointStore interface {
 	GetLatestCheckpoint(checkpointID string) *Checkpoint
 	StoreCheckpoint(checkpoint *Checkpoint)
@@ -40,13 +43,13 @@ func (cp *Checkpoint) LastSeenAt() time.Time {
 }
 
 func (cp *Checkpoint) Update(newPos uint64) {
-	log.Printf("[%T] [DEBUG] update globalPos to <%d>", cp, newPos)
+	logger.Debug("update checkpoint", evently.LogSubscription, cp.id, evently.LogGlobalPos, newPos)
 	cp.globalPos.Store(newPos)
 }
 
 func (cp *Checkpoint) MaxGlobalPos(entries ...*es.Entry) uint64 {
 	if len(entries) == 0 {
-		log.Printf("[%T] [DEBUG] fallback to globalPos=%d", cp, cp.GlobalPosition())
+		logger.Debug("fallback to checkpoint", evently.LogSubscription, cp.id, evently.LogGlobalPos, cp.GlobalPosition())
 		return cp.GlobalPosition() // fallback
 	}
 	maxGlobalPos := entries[0].GlobalPos
@@ -55,6 +58,6 @@ func (cp *Checkpoint) MaxGlobalPos(entries ...*es.Entry) uint64 {
 			maxGlobalPos = e.GlobalPos
 		}
 	}
-	log.Printf("[%T] [DEBUG] max globalPos=%d", cp, maxGlobalPos)
+	logger.Debug("max position of entries", evently.LogSubscription, cp.id, evently.LogGlobalPos, maxGlobalPos)
 	return maxGlobalPos + 1
 }
@@ -0,0 +1,101 @@
+package subscription
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/openyard/evently"
+)
+
+var _ CheckpointStore = (*MemoryCheckpointStore)(nil)
+var _ CheckpointStore = (*FileCheckpointStore)(nil)
+
+// MemoryCheckpointStore keeps checkpoints in memory
+type MemoryCheckpointStore struct {
+	sync.RWMutex
+	checkpoints map[string]*Checkpoint
+}
+
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{checkpoints: make(map[string]*Checkpoint)}
+}
+
+// GetLatestCheckpoint returns a copy of the stored checkpoint or nil if there is none
+func (s *MemoryCheckpointStore) GetLatestCheckpoint(checkpointID string) *Checkpoint {
+	s.RLock()
+	defer s.RUnlock()
+	cp, ok := s.checkpoints[checkpointID]
+	if !ok {
+		return nil
+	}
+	return NewCheckpoint(cp.ID(), cp.GlobalPosition(), cp.LastSeenAt())
+}
+
+// StoreCheckpoint stores a copy of the given checkpoint
+func (s *MemoryCheckpointStore) StoreCheckpoint(checkpoint *Checkpoint) {
+	s.Lock()
+	defer s.Unlock()
+	s.checkpoints[checkpoint.ID()] = NewCheckpoint(checkpoint.ID(), checkpoint.GlobalPosition(), checkpoint.LastSeenAt())
+}
+
+// FileCheckpointStore keeps each checkpoint as JSON file in a directory
+type FileCheckpointStore struct {
+	dir string
+}
+
+func NewFileCheckpointStore(dir string) *FileCheckpointStore {
+	return &FileCheckpointStore{dir: dir}
+}
+
+type checkpointFile struct {
+	ID         string
+	GlobalPos  uint64
+	LastSeenAt time.Time
+}
+
+// GetLatestCheckpoint reads the checkpoint file or returns nil if there is none
+func (s *FileCheckpointStore) GetLatestCheckpoint(checkpointID string) *Checkpoint {
+	b, err := os.ReadFile(s.path(checkpointID))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Error("couldn't read checkpoint", evently.LogSubscription, checkpointID, evently.Err(err))
+		}
+		return nil
+	}
+	var cp checkpointFile
+	if err := json.Unmarshal(b, &cp); err != nil {
+		logger.Error("couldn't read checkpoint", evently.LogSubscription, checkpointID, evently.Err(err))
+		return nil
+	}
+	return NewCheckpoint(cp.ID, cp.GlobalPos, cp.LastSeenAt)
+}
+
+// StoreCheckpoint replaces the checkpoint file atomically
+func (s *FileCheckpointStore) StoreCheckpoint(checkpoint *Checkpoint) {
+	b, _ := json.Marshal(&checkpointFile{checkpoint.ID(), checkpoint.GlobalPosition(), checkpoint.LastSeenAt()})
+	tmp, err := os.CreateTemp(s.dir, "."+checkpoint.ID()+"-*")
+	if err != nil {
+		logger.Error("couldn't store checkpoint", evently.LogSubscription, checkpoint.ID(), evently.Err(err))
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		logger.Error("couldn't store checkpoint", evently.LogSubscription, checkpoint.ID(), evently.Err(err))
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		logger.Error("couldn't store checkpoint", evently.LogSubscription, checkpoint.ID(), evently.Err(err))
+		return
+	}
+	if err := os.Rename(tmp.Name(), s.path(checkpoint.ID())); err != nil {
+		logger.Error("couldn't store checkpoint", evently.LogSubscription, checkpoint.ID(), evently.Err(err))
+	}
+}
+
+func (s *FileCheckpointStore) path(checkpointID string) string {
+	return filepath.Join(s.dir, checkpointID+".json")
+}